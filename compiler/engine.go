@@ -5,6 +5,10 @@
 package compiler
 
 import (
+	"context"
+
+	"github.com/go-vela/compiler/registry"
+
 	"github.com/go-vela/types"
 	"github.com/go-vela/types/library"
 	"github.com/go-vela/types/pipeline"
@@ -22,6 +26,13 @@ type Engine interface {
 	// Parse internally to convert the object to a yaml configuration.
 	Compile(interface{}) (*pipeline.Build, error)
 
+	// CompileWithContext defines a function that produces an
+	// executable representation of a pipeline from an object
+	// and stops compiling when the provided context is done.
+	// This calls ParseWithContext internally to convert the
+	// object to a yaml configuration.
+	CompileWithContext(context.Context, interface{}) (*pipeline.Build, error)
+
 	// Duplicate defines a function that
 	// creates a clone of the Engine.
 	Duplicate() Engine
@@ -30,6 +41,11 @@ type Engine interface {
 	// an object to a yaml configuration.
 	Parse(interface{}) (*yaml.Build, error)
 
+	// ParseWithContext defines a function that converts an
+	// object to a yaml configuration and stops rendering
+	// templated pipelines when the provided context is done.
+	ParseWithContext(context.Context, interface{}) (*yaml.Build, error)
+
 	// ParseRaw defines a function that converts
 	// an object to a string.
 	ParseRaw(interface{}) (string, error)
@@ -38,6 +54,10 @@ type Engine interface {
 	// the yaml configuration is accurate.
 	Validate(*yaml.Build) error
 
+	// Warnings defines a function that returns the non-fatal
+	// problems found while compiling the last yaml configuration.
+	Warnings() []*Warning
+
 	// Clone Compiler Interface Functions
 
 	// CloneStage defines a function that injects the
@@ -58,6 +78,10 @@ type Engine interface {
 	// EnvironmentStep defines a function that injects the environment
 	// variables for a single step into a yaml configuration.
 	EnvironmentStep(*yaml.Step, raw.StringSliceMap) (*yaml.Step, error)
+	// EnvironmentTrace defines a function that returns where each
+	// environment variable for the steps compiled from the last
+	// yaml configuration was declared when tracing is enabled.
+	EnvironmentTrace() []*EnvironmentTrace
 	// EnvironmentServices defines a function that injects the environment
 	// variables for each service into a yaml configuration.
 	EnvironmentServices(yaml.ServiceSlice, raw.StringSliceMap) (yaml.ServiceSlice, error)
@@ -68,10 +92,20 @@ type Engine interface {
 	// for each templated step in every stage in a yaml configuration.
 	// nolint: lll // ignore long line length due to return args
 	ExpandStages(*yaml.Build, map[string]*yaml.Template) (yaml.StageSlice, yaml.SecretSlice, yaml.ServiceSlice, raw.StringSliceMap, error)
+	// ExpandStagesWithContext defines a function that injects the template
+	// for each templated step in every stage in a yaml configuration and
+	// stops fetching and rendering templates when the context is done.
+	// nolint: lll // ignore long line length due to return args
+	ExpandStagesWithContext(context.Context, *yaml.Build, map[string]*yaml.Template) (yaml.StageSlice, yaml.SecretSlice, yaml.ServiceSlice, raw.StringSliceMap, error)
 	// ExpandSteps defines a function that injects the template
 	// for each templated step in a yaml configuration.
 	// nolint: lll // ignore long line length due to return args
 	ExpandSteps(*yaml.Build, map[string]*yaml.Template) (yaml.StepSlice, yaml.SecretSlice, yaml.ServiceSlice, raw.StringSliceMap, error)
+	// ExpandStepsWithContext defines a function that injects the template
+	// for each templated step in a yaml configuration and stops
+	// fetching and rendering templates when the context is done.
+	// nolint: lll // ignore long line length due to return args
+	ExpandStepsWithContext(context.Context, *yaml.Build, map[string]*yaml.Template) (yaml.StepSlice, yaml.SecretSlice, yaml.ServiceSlice, raw.StringSliceMap, error)
 
 	// Init Compiler Interface Functions
 
@@ -81,6 +115,15 @@ type Engine interface {
 	// InitStep step process into a yaml configuration.
 	InitStep(*yaml.Build) (*yaml.Build, error)
 
+	// Matrix Compiler Interface Functions
+
+	// MatrixStages defines a function that expands the matrix for
+	// each stage and each step in every stage in a yaml configuration.
+	MatrixStages(yaml.StageSlice) (yaml.StageSlice, error)
+	// MatrixSteps defines a function that expands the
+	// matrix for each step in a yaml configuration.
+	MatrixSteps(yaml.StepSlice) (yaml.StepSlice, error)
+
 	// Script Compiler Interface Functions
 
 	// ScriptStages defines a function that injects the script
@@ -118,6 +161,10 @@ type Engine interface {
 	// WithComment defines a function that sets
 	// the comment in the Engine.
 	WithComment(string) Engine
+	// WithEnvironmentTrace defines a function that sets whether
+	// the Engine captures where each environment variable for
+	// the compiled steps was declared.
+	WithEnvironmentTrace(bool) Engine
 	// WithFiles defines a function that sets
 	// the changeset files in the Engine.
 	WithFiles([]string) Engine
@@ -133,7 +180,13 @@ type Engine interface {
 	// WithUser defines a function that sets
 	// the library user type in the Engine.
 	WithUser(*library.User) Engine
+	// WithVars defines a function that sets
+	// the pipeline variables in the Engine.
+	WithVars(map[string]interface{}) Engine
 	// WithUser defines a function that sets
 	// the private github client in the Engine.
 	WithPrivateGitHub(string, string) Engine
+	// WithRegistry defines a function that sets the
+	// template service for a template type in the Engine.
+	WithRegistry(string, registry.Service) Engine
 }
@@ -39,10 +39,16 @@ type ModifyResponse struct {
 }
 
 // Compile produces an executable pipeline from a yaml configuration.
+func (c *client) Compile(v interface{}) (*pipeline.Build, error) {
+	return c.CompileWithContext(context.Background(), v)
+}
+
+// CompileWithContext produces an executable pipeline from a yaml
+// configuration and stops compiling when the context is done.
 //
 // nolint: gocyclo,funlen // ignore function length due to comments
-func (c *client) Compile(v interface{}) (*pipeline.Build, error) {
-	p, err := c.Parse(v)
+func (c *client) CompileWithContext(ctx context.Context, v interface{}) (*pipeline.Build, error) {
+	p, err := c.ParseWithContext(ctx, v)
 	if err != nil {
 		return nil, err
 	}
@@ -84,17 +90,22 @@ func (c *client) Compile(v interface{}) (*pipeline.Build, error) {
 		}
 
 		// inject the templates into the stages
-		p.Stages, p.Secrets, p.Services, p.Environment, err = c.ExpandStages(p, tmpls)
+		p.Stages, p.Secrets, p.Services, p.Environment, err = c.ExpandStagesWithContext(ctx, p, tmpls)
 		if err != nil {
 			return nil, err
 		}
 
 		if c.ModificationService.Endpoint != "" {
+			original := p
+
 			// send config to external endpoint for modification
-			p, err = c.modifyConfig(p, c.build, c.repo)
+			p, err = c.modifyConfig(ctx, p, c.build, c.repo)
 			if err != nil {
 				return nil, err
 			}
+
+			// capture the keys that aren't supported by the pipeline types for the modified pipeline
+			c.carry(original, p)
 		}
 
 		// validate the yaml configuration
@@ -103,6 +114,12 @@ func (c *client) Compile(v interface{}) (*pipeline.Build, error) {
 			return nil, err
 		}
 
+		// expand the matrix for the stages
+		p.Stages, err = c.MatrixStages(p.Stages)
+		if err != nil {
+			return nil, err
+		}
+
 		// Create some default global environment inject vars
 		// these are used below to overwrite to an empty
 		// map if they should not be injected into a container
@@ -170,17 +187,22 @@ func (c *client) Compile(v interface{}) (*pipeline.Build, error) {
 	}
 
 	// inject the templates into the steps
-	p.Steps, p.Secrets, p.Services, p.Environment, err = c.ExpandSteps(p, tmpls)
+	p.Steps, p.Secrets, p.Services, p.Environment, err = c.ExpandStepsWithContext(ctx, p, tmpls)
 	if err != nil {
 		return nil, err
 	}
 
 	if c.ModificationService.Endpoint != "" {
+		original := p
+
 		// send config to external endpoint for modification
-		p, err = c.modifyConfig(p, c.build, c.repo)
+		p, err = c.modifyConfig(ctx, p, c.build, c.repo)
 		if err != nil {
 			return nil, err
 		}
+
+		// capture the keys that aren't supported by the pipeline types for the modified pipeline
+		c.carry(original, p)
 	}
 
 	// validate the yaml configuration
@@ -189,6 +211,12 @@ func (c *client) Compile(v interface{}) (*pipeline.Build, error) {
 		return nil, err
 	}
 
+	// expand the matrix for the steps
+	p.Steps, err = c.MatrixSteps(p.Steps)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create some default global environment inject vars
 	// these are used below to overwrite to an empty
 	// map if they should not be injected into a container
@@ -252,7 +280,7 @@ func errorHandler(resp *http.Response, err error, attempts int) (*http.Response,
 
 // modifyConfig sends the configuration to external http endpoint for modification.
 // nolint:lll // parameter struct references push line limit
-func (c *client) modifyConfig(build *yaml.Build, libraryBuild *library.Build, repo *library.Repo) (*yaml.Build, error) {
+func (c *client) modifyConfig(ctx context.Context, build *yaml.Build, libraryBuild *library.Build, repo *library.Repo) (*yaml.Build, error) {
 	// create request to send to endpoint
 	data, err := yml.Marshal(build)
 	if err != nil {
@@ -291,9 +319,14 @@ func (c *client) modifyConfig(build *yaml.Build, libraryBuild *library.Build, re
 	}
 
 	// ensure the overall request(s) do not take over the defined timeout
-	ctx, cancel := context.WithTimeout(req.Request.Context(), c.ModificationService.Timeout)
-	defer cancel()
-	req.WithContext(ctx)
+	if c.ModificationService.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.ModificationService.Timeout)
+		defer cancel()
+	}
+
+	req = req.WithContext(ctx)
 
 	// add content-type and auth headers
 	req.Header.Add("Content-Type", "application/json")
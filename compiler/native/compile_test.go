@@ -5,12 +5,14 @@
 package native
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
-	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -53,13 +55,13 @@ func TestNative_Compile_StagesPipeline(t *testing.T) {
 		},
 	}
 
-	initEnv := environment(nil, m, nil, nil)
+	initEnv := environment(nil, m, nil, nil, nil)
 	initEnv["HELLO"] = "Hello, Global Environment"
 
-	cloneEnv := environment(nil, m, nil, nil)
+	cloneEnv := environment(nil, m, nil, nil, nil)
 	cloneEnv["HELLO"] = "Hello, Global Environment"
 
-	installEnv := environment(nil, m, nil, nil)
+	installEnv := environment(nil, m, nil, nil, nil)
 	installEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	installEnv["GRADLE_USER_HOME"] = ".gradle"
 	installEnv["HOME"] = "/root"
@@ -67,7 +69,7 @@ func TestNative_Compile_StagesPipeline(t *testing.T) {
 	installEnv["VELA_BUILD_SCRIPT"] = generateScriptPosix([]string{"./gradlew downloadDependencies"})
 	installEnv["HELLO"] = "Hello, Global Environment"
 
-	testEnv := environment(nil, m, nil, nil)
+	testEnv := environment(nil, m, nil, nil, nil)
 	testEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	testEnv["GRADLE_USER_HOME"] = ".gradle"
 	testEnv["HOME"] = "/root"
@@ -75,7 +77,7 @@ func TestNative_Compile_StagesPipeline(t *testing.T) {
 	testEnv["VELA_BUILD_SCRIPT"] = generateScriptPosix([]string{"./gradlew check"})
 	testEnv["HELLO"] = "Hello, Global Environment"
 
-	buildEnv := environment(nil, m, nil, nil)
+	buildEnv := environment(nil, m, nil, nil, nil)
 	buildEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	buildEnv["GRADLE_USER_HOME"] = ".gradle"
 	buildEnv["HOME"] = "/root"
@@ -83,7 +85,7 @@ func TestNative_Compile_StagesPipeline(t *testing.T) {
 	buildEnv["VELA_BUILD_SCRIPT"] = generateScriptPosix([]string{"./gradlew build"})
 	buildEnv["HELLO"] = "Hello, Global Environment"
 
-	dockerEnv := environment(nil, m, nil, nil)
+	dockerEnv := environment(nil, m, nil, nil, nil)
 	dockerEnv["PARAMETER_REGISTRY"] = "index.docker.io"
 	dockerEnv["PARAMETER_REPO"] = "github/octocat"
 	dockerEnv["PARAMETER_TAGS"] = "latest,dev"
@@ -413,13 +415,13 @@ func TestNative_Compile_StepsPipeline(t *testing.T) {
 		},
 	}
 
-	initEnv := environment(nil, m, nil, nil)
+	initEnv := environment(nil, m, nil, nil, nil)
 	initEnv["HELLO"] = "Hello, Global Environment"
 
-	cloneEnv := environment(nil, m, nil, nil)
+	cloneEnv := environment(nil, m, nil, nil, nil)
 	cloneEnv["HELLO"] = "Hello, Global Environment"
 
-	installEnv := environment(nil, m, nil, nil)
+	installEnv := environment(nil, m, nil, nil, nil)
 	installEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	installEnv["GRADLE_USER_HOME"] = ".gradle"
 	installEnv["HOME"] = "/root"
@@ -427,7 +429,7 @@ func TestNative_Compile_StepsPipeline(t *testing.T) {
 	installEnv["VELA_BUILD_SCRIPT"] = generateScriptPosix([]string{"./gradlew downloadDependencies"})
 	installEnv["HELLO"] = "Hello, Global Environment"
 
-	testEnv := environment(nil, m, nil, nil)
+	testEnv := environment(nil, m, nil, nil, nil)
 	testEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	testEnv["GRADLE_USER_HOME"] = ".gradle"
 	testEnv["HOME"] = "/root"
@@ -435,7 +437,7 @@ func TestNative_Compile_StepsPipeline(t *testing.T) {
 	testEnv["VELA_BUILD_SCRIPT"] = generateScriptPosix([]string{"./gradlew check"})
 	testEnv["HELLO"] = "Hello, Global Environment"
 
-	buildEnv := environment(nil, m, nil, nil)
+	buildEnv := environment(nil, m, nil, nil, nil)
 	buildEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	buildEnv["GRADLE_USER_HOME"] = ".gradle"
 	buildEnv["HOME"] = "/root"
@@ -443,7 +445,7 @@ func TestNative_Compile_StepsPipeline(t *testing.T) {
 	buildEnv["VELA_BUILD_SCRIPT"] = generateScriptPosix([]string{"./gradlew build"})
 	buildEnv["HELLO"] = "Hello, Global Environment"
 
-	dockerEnv := environment(nil, m, nil, nil)
+	dockerEnv := environment(nil, m, nil, nil, nil)
 	dockerEnv["PARAMETER_REGISTRY"] = "index.docker.io"
 	dockerEnv["PARAMETER_REPO"] = "github/octocat"
 	dockerEnv["PARAMETER_TAGS"] = "latest,dev"
@@ -614,11 +616,11 @@ func TestNative_Compile_StagesPipelineTemplate(t *testing.T) {
 		},
 	}
 
-	setupEnv := environment(nil, m, nil, nil)
+	setupEnv := environment(nil, m, nil, nil, nil)
 	setupEnv["bar"] = "test4"
 	setupEnv["star"] = "test3"
 
-	installEnv := environment(nil, m, nil, nil)
+	installEnv := environment(nil, m, nil, nil, nil)
 	installEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	installEnv["GRADLE_USER_HOME"] = ".gradle"
 	installEnv["HOME"] = "/root"
@@ -627,7 +629,7 @@ func TestNative_Compile_StagesPipelineTemplate(t *testing.T) {
 	installEnv["bar"] = "test4"
 	installEnv["star"] = "test3"
 
-	testEnv := environment(nil, m, nil, nil)
+	testEnv := environment(nil, m, nil, nil, nil)
 	testEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	testEnv["GRADLE_USER_HOME"] = ".gradle"
 	testEnv["HOME"] = "/root"
@@ -636,7 +638,7 @@ func TestNative_Compile_StagesPipelineTemplate(t *testing.T) {
 	testEnv["bar"] = "test4"
 	testEnv["star"] = "test3"
 
-	buildEnv := environment(nil, m, nil, nil)
+	buildEnv := environment(nil, m, nil, nil, nil)
 	buildEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	buildEnv["GRADLE_USER_HOME"] = ".gradle"
 	buildEnv["HOME"] = "/root"
@@ -645,14 +647,14 @@ func TestNative_Compile_StagesPipelineTemplate(t *testing.T) {
 	buildEnv["bar"] = "test4"
 	buildEnv["star"] = "test3"
 
-	dockerEnv := environment(nil, m, nil, nil)
+	dockerEnv := environment(nil, m, nil, nil, nil)
 	dockerEnv["PARAMETER_REGISTRY"] = "index.docker.io"
 	dockerEnv["PARAMETER_REPO"] = "github/octocat"
 	dockerEnv["PARAMETER_TAGS"] = "latest,dev"
 	dockerEnv["bar"] = "test4"
 	dockerEnv["star"] = "test3"
 
-	serviceEnv := environment(nil, m, nil, nil)
+	serviceEnv := environment(nil, m, nil, nil, nil)
 	serviceEnv["bar"] = "test4"
 	serviceEnv["star"] = "test3"
 
@@ -873,11 +875,11 @@ func TestNative_Compile_StepsPipelineTemplate(t *testing.T) {
 		},
 	}
 
-	setupEnv := environment(nil, m, nil, nil)
+	setupEnv := environment(nil, m, nil, nil, nil)
 	setupEnv["bar"] = "test4"
 	setupEnv["star"] = "test3"
 
-	installEnv := environment(nil, m, nil, nil)
+	installEnv := environment(nil, m, nil, nil, nil)
 	installEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	installEnv["GRADLE_USER_HOME"] = ".gradle"
 	installEnv["HOME"] = "/root"
@@ -886,7 +888,7 @@ func TestNative_Compile_StepsPipelineTemplate(t *testing.T) {
 	installEnv["bar"] = "test4"
 	installEnv["star"] = "test3"
 
-	testEnv := environment(nil, m, nil, nil)
+	testEnv := environment(nil, m, nil, nil, nil)
 	testEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	testEnv["GRADLE_USER_HOME"] = ".gradle"
 	testEnv["HOME"] = "/root"
@@ -895,7 +897,7 @@ func TestNative_Compile_StepsPipelineTemplate(t *testing.T) {
 	testEnv["bar"] = "test4"
 	testEnv["star"] = "test3"
 
-	buildEnv := environment(nil, m, nil, nil)
+	buildEnv := environment(nil, m, nil, nil, nil)
 	buildEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
 	buildEnv["GRADLE_USER_HOME"] = ".gradle"
 	buildEnv["HOME"] = "/root"
@@ -904,14 +906,14 @@ func TestNative_Compile_StepsPipelineTemplate(t *testing.T) {
 	buildEnv["bar"] = "test4"
 	buildEnv["star"] = "test3"
 
-	dockerEnv := environment(nil, m, nil, nil)
+	dockerEnv := environment(nil, m, nil, nil, nil)
 	dockerEnv["PARAMETER_REGISTRY"] = "index.docker.io"
 	dockerEnv["PARAMETER_REPO"] = "github/octocat"
 	dockerEnv["PARAMETER_TAGS"] = "latest,dev"
 	dockerEnv["bar"] = "test4"
 	dockerEnv["star"] = "test3"
 
-	serviceEnv := environment(nil, m, nil, nil)
+	serviceEnv := environment(nil, m, nil, nil, nil)
 	serviceEnv["bar"] = "test4"
 	serviceEnv["star"] = "test3"
 
@@ -1054,6 +1056,54 @@ func TestNative_Compile_StepsPipelineTemplate(t *testing.T) {
 	}
 }
 
+func TestNative_CompileWithContext_Canceled(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	resp := httptest.NewRecorder()
+	_, engine := gin.CreateTestContext(resp)
+
+	// setup mock server
+	engine.GET("/api/v3/repos/foo/bar/contents/:path", func(c *gin.Context) {
+		c.Header("Content-Type", "application/json")
+		c.Status(http.StatusOK)
+		c.File("testdata/template.json")
+	})
+
+	s := httptest.NewServer(engine)
+	defer s.Close()
+
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.Bool("github-driver", true, "doc")
+	set.String("github-url", s.URL, "doc")
+	set.String("github-token", "", "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// run test
+	yaml, err := ioutil.ReadFile("testdata/steps_pipeline_template.yml")
+	if err != nil {
+		t.Errorf("Reading yaml file return err: %v", err)
+	}
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	got, err := compiler.CompileWithContext(ctx, yaml)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("CompileWithContext returned err %v, want %v", err, context.Canceled)
+	}
+
+	if got != nil {
+		t.Errorf("CompileWithContext is %v, want nil", got)
+	}
+}
+
 func TestNative_Compile_InvalidType(t *testing.T) {
 	// setup context
 	gin.SetMode(gin.TestMode)
@@ -1098,80 +1148,6 @@ func TestNative_Compile_InvalidType(t *testing.T) {
 		},
 	}
 
-	gradleEnv := environment(nil, m, nil, nil)
-	gradleEnv["GRADLE_OPTS"] = "-Dorg.gradle.daemon=false -Dorg.gradle.workers.max=1 -Dorg.gradle.parallel=false"
-	gradleEnv["GRADLE_USER_HOME"] = ".gradle"
-
-	dockerEnv := environment(nil, m, nil, nil)
-	dockerEnv["PARAMETER_REGISTRY"] = "index.docker.io"
-	dockerEnv["PARAMETER_REPO"] = "github/octocat"
-	dockerEnv["PARAMETER_TAGS"] = "latest,dev"
-
-	want := &pipeline.Build{
-		Version: "1",
-		ID:      "__0",
-		Metadata: pipeline.Metadata{
-			Clone:       true,
-			Template:    false,
-			Environment: []string{"steps", "services", "secrets"},
-		},
-		Steps: pipeline.ContainerSlice{
-			&pipeline.Container{
-				ID:          "step___0_init",
-				Directory:   "/vela/src/foo//",
-				Environment: environment(nil, m, nil, nil),
-				Image:       "#init",
-				Name:        "init",
-				Number:      1,
-				Pull:        "not_present",
-			},
-			&pipeline.Container{
-				ID:          "step___0_clone",
-				Directory:   "/vela/src/foo//",
-				Environment: environment(nil, m, nil, nil),
-				Image:       "target/vela-git:v0.4.0",
-				Name:        "clone",
-				Number:      2,
-				Pull:        "not_present",
-			},
-			&pipeline.Container{
-				ID:          "step___0_docker",
-				Directory:   "/vela/src/foo//",
-				Image:       "plugins/docker:18.09",
-				Environment: dockerEnv,
-				Name:        "docker",
-				Number:      3,
-				Pull:        "always",
-				Secrets: pipeline.StepSecretSlice{
-					&pipeline.StepSecret{
-						Source: "docker_username",
-						Target: "registry_username",
-					},
-					&pipeline.StepSecret{
-						Source: "docker_password",
-						Target: "registry_password",
-					},
-				},
-			},
-		},
-		Secrets: pipeline.SecretSlice{
-			&pipeline.Secret{
-				Name:   "docker_username",
-				Key:    "org/repo/docker/username",
-				Engine: "native",
-				Type:   "repo",
-				Origin: &pipeline.Container{},
-			},
-			&pipeline.Secret{
-				Name:   "docker_password",
-				Key:    "org/repo/docker/password",
-				Engine: "vault",
-				Type:   "repo",
-				Origin: &pipeline.Container{},
-			},
-		},
-	}
-
 	// run test
 	yaml, err := ioutil.ReadFile("testdata/invalid_type.yml")
 	if err != nil {
@@ -1186,12 +1162,17 @@ func TestNative_Compile_InvalidType(t *testing.T) {
 	compiler.WithMetadata(m)
 
 	got, err := compiler.Compile(yaml)
-	if err != nil {
-		t.Errorf("Compile returned err: %v", err)
+	if err == nil {
+		t.Errorf("Compile should have returned err")
 	}
 
-	if !reflect.DeepEqual(got, want) {
-		t.Errorf("Compile is %v, want %v", got, want)
+	want := `unsupported template type "bla" for template gradle`
+	if err != nil && !strings.Contains(err.Error(), want) {
+		t.Errorf("Compile returned err %v, want %v", err, want)
+	}
+
+	if got != nil {
+		t.Errorf("Compile is %v, want nil", got)
 	}
 }
 
@@ -1222,10 +1203,10 @@ func TestNative_Compile_Clone(t *testing.T) {
 		},
 	}
 
-	fooEnv := environment(nil, m, nil, nil)
+	fooEnv := environment(nil, m, nil, nil, nil)
 	fooEnv["PARAMETER_REGISTRY"] = "foo"
 
-	cloneEnv := environment(nil, m, nil, nil)
+	cloneEnv := environment(nil, m, nil, nil, nil)
 	cloneEnv["PARAMETER_DEPTH"] = "5"
 
 	wantFalse := &pipeline.Build{
@@ -1240,7 +1221,7 @@ func TestNative_Compile_Clone(t *testing.T) {
 			&pipeline.Container{
 				ID:          "step___0_init",
 				Directory:   "/vela/src/foo//",
-				Environment: environment(nil, m, nil, nil),
+				Environment: environment(nil, m, nil, nil, nil),
 				Image:       "#init",
 				Name:        "init",
 				Number:      1,
@@ -1270,7 +1251,7 @@ func TestNative_Compile_Clone(t *testing.T) {
 			&pipeline.Container{
 				ID:          "step___0_init",
 				Directory:   "/vela/src/foo//",
-				Environment: environment(nil, m, nil, nil),
+				Environment: environment(nil, m, nil, nil, nil),
 				Image:       "#init",
 				Name:        "init",
 				Number:      1,
@@ -1279,7 +1260,7 @@ func TestNative_Compile_Clone(t *testing.T) {
 			&pipeline.Container{
 				ID:          "step___0_clone",
 				Directory:   "/vela/src/foo//",
-				Environment: environment(nil, m, nil, nil),
+				Environment: environment(nil, m, nil, nil, nil),
 				Image:       "target/vela-git:v0.4.0",
 				Name:        "clone",
 				Number:      2,
@@ -1309,7 +1290,7 @@ func TestNative_Compile_Clone(t *testing.T) {
 			&pipeline.Container{
 				ID:          "step___0_init",
 				Directory:   "/vela/src/foo//",
-				Environment: environment(nil, m, nil, nil),
+				Environment: environment(nil, m, nil, nil, nil),
 				Image:       "#init",
 				Name:        "init",
 				Number:      1,
@@ -1409,10 +1390,10 @@ func TestNative_Compile_Pipeline_Type(t *testing.T) {
 		},
 	}
 
-	defaultFooEnv := environment(nil, m, nil, nil)
+	defaultFooEnv := environment(nil, m, nil, nil, nil)
 	defaultFooEnv["PARAMETER_REGISTRY"] = "foo"
 
-	defaultEnv := environment(nil, m, nil, nil)
+	defaultEnv := environment(nil, m, nil, nil, nil)
 	wantDefault := &pipeline.Build{
 		Version: "1",
 		ID:      "__0",
@@ -1453,10 +1434,10 @@ func TestNative_Compile_Pipeline_Type(t *testing.T) {
 
 	goPipelineType := "go"
 
-	goFooEnv := environment(nil, m, &library.Repo{PipelineType: &goPipelineType}, nil)
+	goFooEnv := environment(nil, m, &library.Repo{PipelineType: &goPipelineType}, nil, nil)
 	goFooEnv["PARAMETER_REGISTRY"] = "foo"
 
-	defaultGoEnv := environment(nil, m, &library.Repo{PipelineType: &goPipelineType}, nil)
+	defaultGoEnv := environment(nil, m, &library.Repo{PipelineType: &goPipelineType}, nil, nil)
 	wantGo := &pipeline.Build{
 		Version: "1",
 		ID:      "__0",
@@ -1497,10 +1478,10 @@ func TestNative_Compile_Pipeline_Type(t *testing.T) {
 
 	starPipelineType := "starlark"
 
-	starlarkFooEnv := environment(nil, m, &library.Repo{PipelineType: &starPipelineType}, nil)
+	starlarkFooEnv := environment(nil, m, &library.Repo{PipelineType: &starPipelineType}, nil, nil)
 	starlarkFooEnv["PARAMETER_REGISTRY"] = "foo"
 
-	defaultStarlarkEnv := environment(nil, m, &library.Repo{PipelineType: &starPipelineType}, nil)
+	defaultStarlarkEnv := environment(nil, m, &library.Repo{PipelineType: &starPipelineType}, nil, nil)
 	wantStarlark := &pipeline.Build{
 		Version: "1",
 		ID:      "__0",
@@ -1699,13 +1680,13 @@ func Test_client_modifyConfig(t *testing.T) {
 		},
 		Steps: yaml.StepSlice{
 			&yaml.Step{
-				Environment: environment(nil, m, nil, nil),
+				Environment: environment(nil, m, nil, nil, nil),
 				Image:       "#init",
 				Name:        "init",
 				Pull:        "not_present",
 			},
 			&yaml.Step{
-				Environment: environment(nil, m, nil, nil),
+				Environment: environment(nil, m, nil, nil, nil),
 				Image:       "target/vela-git:v0.3.0",
 				Name:        "clone",
 				Pull:        "not_present",
@@ -1732,13 +1713,13 @@ func Test_client_modifyConfig(t *testing.T) {
 		},
 		Steps: yaml.StepSlice{
 			&yaml.Step{
-				Environment: environment(nil, m, nil, nil),
+				Environment: environment(nil, m, nil, nil, nil),
 				Image:       "#init",
 				Name:        "init",
 				Pull:        "not_present",
 			},
 			&yaml.Step{
-				Environment: environment(nil, m, nil, nil),
+				Environment: environment(nil, m, nil, nil, nil),
 				Image:       "target/vela-git:v0.3.0",
 				Name:        "clone",
 				Pull:        "not_present",
@@ -1885,7 +1866,7 @@ func Test_client_modifyConfig(t *testing.T) {
 					Endpoint: tt.args.endpoint,
 				},
 			}
-			got, err := compiler.modifyConfig(tt.args.build, tt.args.libraryBuild, tt.args.repo)
+			got, err := compiler.modifyConfig(context.Background(), tt.args.build, tt.args.libraryBuild, tt.args.repo)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("modifyConfig() error = %v, wantErr %v", err, tt.wantErr)
 				return
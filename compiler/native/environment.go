@@ -7,8 +7,12 @@ package native
 import (
 	"fmt"
 	"os"
+	"path"
+	"sort"
 	"strings"
 
+	"github.com/go-vela/compiler/compiler"
+
 	"github.com/go-vela/types"
 	"github.com/go-vela/types/constants"
 	"github.com/go-vela/types/library"
@@ -23,7 +27,7 @@ func (c *client) EnvironmentStages(s yaml.StageSlice, globalEnv raw.StringSliceM
 	// iterate through all stages
 	for _, stage := range s {
 		// inject the environment variables into the steps for the stage
-		steps, err := c.EnvironmentSteps(stage.Steps, globalEnv)
+		steps, err := c.environmentSteps(stage.Steps, globalEnv, stage.Name)
 		if err != nil {
 			return nil, err
 		}
@@ -38,12 +42,31 @@ func (c *client) EnvironmentStages(s yaml.StageSlice, globalEnv raw.StringSliceM
 // for each step in a yaml configuration.
 // nolint:lll // ignore function line length
 func (c *client) EnvironmentSteps(s yaml.StepSlice, globalEnv raw.StringSliceMap) (yaml.StepSlice, error) {
+	return c.environmentSteps(s, globalEnv, "")
+}
+
+// environmentSteps is a helper function that injects the environment
+// variables for each step in a yaml configuration and captures where
+// each environment variable was declared when tracing is enabled.
+// The stage is empty when the steps are not part of a stage.
+//
+// nolint:lll // ignore function line length
+func (c *client) environmentSteps(s yaml.StepSlice, globalEnv raw.StringSliceMap, stage string) (yaml.StepSlice, error) {
 	// iterate through all steps
 	for _, step := range s {
-		_, err := c.EnvironmentStep(step, globalEnv)
+		var t *tracer
+		if c.traceEnv {
+			t = newTracer()
+		}
+
+		_, err := c.environmentStep(step, globalEnv, t)
 		if err != nil {
 			return nil, err
 		}
+
+		if t != nil {
+			c.traces = append(c.traces, t.trace(stage, step.Name))
+		}
 	}
 
 	return s, nil
@@ -52,27 +75,33 @@ func (c *client) EnvironmentSteps(s yaml.StepSlice, globalEnv raw.StringSliceMap
 // EnvironmentStep injects environment variables
 // a single step in a yaml configuration.
 func (c *client) EnvironmentStep(s *yaml.Step, globalEnv raw.StringSliceMap) (*yaml.Step, error) {
+	return c.environmentStep(s, globalEnv, nil)
+}
+
+// environmentStep is a helper function that injects the environment
+// variables for a single step in a yaml configuration and captures
+// where each environment variable was declared with the tracer.
+// The tracer may be nil.
+//
+// nolint:lll // ignore function line length
+func (c *client) environmentStep(s *yaml.Step, globalEnv raw.StringSliceMap, t *tracer) (*yaml.Step, error) {
 	// make empty map of environment variables
 	env := make(map[string]string)
 	// gather set of default environment variables
-	defaultEnv := environment(c.build, c.metadata, c.repo, c.user)
+	defaultEnv := environment(c.build, c.metadata, c.repo, c.user, &c.Platform)
 
 	// check if the compiler is setup for a local pipeline
 	// and the step isn't setup to run in a detached state
 	if c.local && !s.Detach {
-		// capture all environment variables from the local environment
-		for _, e := range os.Environ() {
-			// split the environment variable on = into a key value pair
-			// nolint: gomnd // ignore magic number
-			parts := strings.SplitN(e, "=", 2)
-
-			env[parts[0]] = parts[1]
-		}
+		// capture the allowed environment variables from the local environment
+		env = c.localEnvironment()
+		t.setAll(env, compiler.SourceLocal)
 	}
 
 	// inject the declared global environment
 	// WARNING: local env can override global
 	env = appendMap(env, globalEnv)
+	t.setAll(globalEnv, compiler.SourceGlobal)
 
 	// inject the declared environment
 	// variables to the build step
@@ -80,6 +109,12 @@ func (c *client) EnvironmentStep(s *yaml.Step, globalEnv raw.StringSliceMap) (*y
 		env[k] = v
 	}
 
+	t.setAll(s.Environment, compiler.SourceStep)
+
+	// capture warnings for the declared environment
+	// overwritten by the default environment
+	c.shadowedEnvironment(s, globalEnv, defaultEnv)
+
 	// inject the default environment
 	// variables to the build step
 	// we do this after injecting the declared environment
@@ -88,6 +123,8 @@ func (c *client) EnvironmentStep(s *yaml.Step, globalEnv raw.StringSliceMap) (*y
 		env[k] = v
 	}
 
+	t.setAll(defaultEnv, compiler.SourcePlatform)
+
 	// inject the declared parameter
 	// variables to the build step
 	for k, v := range s.Parameters {
@@ -102,6 +139,7 @@ func (c *client) EnvironmentStep(s *yaml.Step, globalEnv raw.StringSliceMap) (*y
 		// parameter values are passed to the image
 		// as string environment variables
 		env[k] = library.ToString(v)
+		t.set(k, env[k], compiler.SourceParameter)
 	}
 
 	// overwrite existing build step environment
@@ -110,6 +148,45 @@ func (c *client) EnvironmentStep(s *yaml.Step, globalEnv raw.StringSliceMap) (*y
 	return s, nil
 }
 
+// shadowedEnvironment is a helper function that captures a warning for
+// each environment variable declared by the step or the pipeline with
+// a different value than the default environment that overwrites it.
+//
+// nolint: lll // ignore long line length due to warning messages
+func (c *client) shadowedEnvironment(s *yaml.Step, globalEnv raw.StringSliceMap, defaultEnv map[string]string) {
+	keys := []string{}
+
+	for k := range defaultEnv {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		// check if the step declares the variable
+		if v, ok := s.Environment[k]; ok {
+			if v != defaultEnv[k] {
+				c.warn(&compiler.Warning{
+					Code:    compiler.WarningEnvironmentShadowed,
+					Step:    s.Name,
+					Message: fmt.Sprintf("environment variable %s declared for step %s is overwritten by the platform", k, s.Name),
+				}, s, "environment")
+			}
+
+			continue
+		}
+
+		// check if the pipeline declares the variable
+		if v, ok := globalEnv[k]; ok && v != defaultEnv[k] {
+			c.warn(&compiler.Warning{
+				Code:     compiler.WarningEnvironmentShadowed,
+				Message:  fmt.Sprintf("environment variable %s declared for the pipeline is overwritten by the platform", k),
+				Position: c.positions.top("environment"),
+			}, nil, "")
+		}
+	}
+}
+
 // EnvironmentServices injects environment variables
 // for each service in a yaml configuration.
 // nolint:lll // ignore function line length
@@ -119,7 +196,7 @@ func (c *client) EnvironmentServices(s yaml.ServiceSlice, globalEnv raw.StringSl
 		// make empty map of environment variables
 		env := make(map[string]string)
 		// gather set of default environment variables
-		defaultEnv := environment(c.build, c.metadata, c.repo, c.user)
+		defaultEnv := environment(c.build, c.metadata, c.repo, c.user, &c.Platform)
 
 		// inject the declared global environment
 		// WARNING: local env can override global
@@ -160,18 +237,12 @@ func (c *client) EnvironmentSecrets(s yaml.SecretSlice, globalEnv raw.StringSlic
 		// make empty map of environment variables
 		env := make(map[string]string)
 		// gather set of default environment variables
-		defaultEnv := environment(c.build, c.metadata, c.repo, c.user)
+		defaultEnv := environment(c.build, c.metadata, c.repo, c.user, &c.Platform)
 
 		// check if the compiler is setup for a local pipeline
 		if c.local {
-			// capture all environment variables from the local environment
-			for _, e := range os.Environ() {
-				// split the environment variable on = into a key value pair
-				// nolint: gomnd // ignore magic number
-				parts := strings.SplitN(e, "=", 2)
-
-				env[parts[0]] = parts[1]
-			}
+			// capture the allowed environment variables from the local environment
+			env = c.localEnvironment()
 		}
 
 		// inject the declared global environment
@@ -219,18 +290,12 @@ func (c *client) EnvironmentBuild() map[string]string {
 	// make empty map of environment variables
 	env := make(map[string]string)
 	// gather set of default environment variables
-	defaultEnv := environment(c.build, c.metadata, c.repo, c.user)
+	defaultEnv := environment(c.build, c.metadata, c.repo, c.user, &c.Platform)
 
 	// check if the compiler is setup for a local pipeline
 	if c.local {
-		// capture all environment variables from the local environment
-		for _, e := range os.Environ() {
-			// split the environment variable on = into a key value pair
-			// nolint: gomnd // ignore magic number
-			parts := strings.SplitN(e, "=", 2)
-
-			env[parts[0]] = parts[1]
-		}
+		// capture the allowed environment variables from the local environment
+		env = c.localEnvironment()
 	}
 
 	// inject the default environment
@@ -244,6 +309,54 @@ func (c *client) EnvironmentBuild() map[string]string {
 	return env
 }
 
+// localEnvironment is a helper function that captures the variables
+// from the host environment allowed by the policy for passing the
+// host environment into the containers for a local pipeline.
+func (c *client) localEnvironment() map[string]string {
+	env := make(map[string]string)
+
+	if c.LocalEnvironment.Mode == LocalEnvironmentNone {
+		return env
+	}
+
+	for _, e := range os.Environ() {
+		// split the environment variable on = into a key value pair
+		// nolint: gomnd // ignore magic number
+		parts := strings.SplitN(e, "=", 2)
+
+		if !c.LocalEnvironment.allowed(parts[0]) {
+			continue
+		}
+
+		env[parts[0]] = parts[1]
+	}
+
+	return env
+}
+
+// allowed returns true when the variable matches the
+// allowlist, or no allowlist is provided, and the
+// variable doesn't match the denylist.
+func (cfg LocalEnvironmentConfig) allowed(name string) bool {
+	if len(cfg.Allow) > 0 && !matchAny(cfg.Allow, name) {
+		return false
+	}
+
+	return !matchAny(cfg.Deny, name)
+}
+
+// helper function that returns true when the name matches any of the glob patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		// the patterns are verified when creating the compiler
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 // helper function to merge two maps together.
 func appendMap(originalMap, otherMap map[string]string) map[string]string {
 	for key, value := range otherMap {
@@ -254,30 +367,34 @@ func appendMap(originalMap, otherMap map[string]string) map[string]string {
 }
 
 // helper function that creates the standard set of environment variables for a pipeline.
+// The variables for the platform are empty when the details aren't provided.
 //
 // nolint: lll // ignore line length due to number of parameters provided
-func environment(b *library.Build, m *types.Metadata, r *library.Repo, u *library.User) map[string]string {
+func environment(b *library.Build, m *types.Metadata, r *library.Repo, u *library.User, p *PlatformConfig) map[string]string {
 	// set default workspace
 	workspace := constants.WorkspaceDefault
-	notImplemented := "TODO"
-	channel := notImplemented
+	channel := ""
+
+	if p == nil {
+		p = new(PlatformConfig)
+	}
 
 	env := make(map[string]string)
 
 	// vela specific environment variables
 	env["VELA"] = library.ToString(true)
-	env["VELA_ADDR"] = notImplemented
-	env["VELA_CHANNEL"] = notImplemented
-	env["VELA_DATABASE"] = notImplemented
-	env["VELA_DISTRIBUTION"] = notImplemented
-	env["VELA_HOST"] = notImplemented
-	env["VELA_NETRC_MACHINE"] = notImplemented
+	env["VELA_ADDR"] = ""
+	env["VELA_CHANNEL"] = ""
+	env["VELA_DATABASE"] = ""
+	env["VELA_DISTRIBUTION"] = p.Distribution
+	env["VELA_HOST"] = ""
+	env["VELA_NETRC_MACHINE"] = ""
 	env["VELA_NETRC_PASSWORD"] = u.GetToken()
 	env["VELA_NETRC_USERNAME"] = "x-oauth-basic"
-	env["VELA_QUEUE"] = notImplemented
-	env["VELA_RUNTIME"] = notImplemented
-	env["VELA_SOURCE"] = notImplemented
-	env["VELA_VERSION"] = notImplemented
+	env["VELA_QUEUE"] = ""
+	env["VELA_RUNTIME"] = p.Runtime
+	env["VELA_SOURCE"] = ""
+	env["VELA_VERSION"] = p.Version
 	env["CI"] = "vela"
 
 	// populate environment variables from metadata
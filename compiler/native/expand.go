@@ -5,9 +5,15 @@
 package native
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
+	"github.com/go-vela/compiler/compiler"
+	"github.com/go-vela/compiler/registry"
+	"github.com/go-vela/compiler/template"
 	"github.com/go-vela/compiler/template/native"
 	"github.com/go-vela/compiler/template/starlark"
 	"github.com/spf13/afero"
@@ -17,15 +23,38 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// origin represents the template that introduced a
+// step, secret or service while expanding templates.
+type origin struct {
+	// template is the name of the template rendered
+	template string
+	// step is the templated step the template was rendered for
+	step *yaml.Step
+}
+
+// String returns the human-readable representation of the origin.
+func (o *origin) String() string {
+	return fmt.Sprintf("template %s for step %s", o.template, o.step.Name)
+}
+
 // ExpandStages injects the template for each
 // templated step in every stage in a yaml configuration.
 //
 // nolint: lll // ignore long line length due to variable names
 func (c *client) ExpandStages(s *yaml.Build, tmpls map[string]*yaml.Template) (yaml.StageSlice, yaml.SecretSlice, yaml.ServiceSlice, raw.StringSliceMap, error) {
+	return c.ExpandStagesWithContext(context.Background(), s, tmpls)
+}
+
+// ExpandStagesWithContext injects the template for each templated step
+// in every stage in a yaml configuration and stops fetching and
+// rendering templates when the provided context is done.
+//
+// nolint: lll // ignore long line length due to variable names
+func (c *client) ExpandStagesWithContext(ctx context.Context, s *yaml.Build, tmpls map[string]*yaml.Template) (yaml.StageSlice, yaml.SecretSlice, yaml.ServiceSlice, raw.StringSliceMap, error) {
 	// iterate through all stages
 	for _, stage := range s.Stages {
 		// inject the templates into the steps for the stage
-		steps, secrets, services, environment, err := c.ExpandSteps(&yaml.Build{Steps: stage.Steps, Secrets: s.Secrets, Services: s.Services, Environment: s.Environment}, tmpls)
+		steps, secrets, services, environment, err := c.ExpandStepsWithContext(ctx, &yaml.Build{Steps: stage.Steps, Secrets: s.Secrets, Services: s.Services, Environment: s.Environment}, tmpls)
 		if err != nil {
 			return nil, nil, nil, nil, err
 		}
@@ -42,8 +71,29 @@ func (c *client) ExpandStages(s *yaml.Build, tmpls map[string]*yaml.Template) (y
 // ExpandSteps injects the template for each
 // templated step in a yaml configuration.
 //
-// nolint: lll,funlen,gocyclo // ignore long line length due to variable names
+// nolint: lll // ignore long line length due to variable names
 func (c *client) ExpandSteps(s *yaml.Build, tmpls map[string]*yaml.Template) (yaml.StepSlice, yaml.SecretSlice, yaml.ServiceSlice, raw.StringSliceMap, error) {
+	return c.ExpandStepsWithContext(context.Background(), s, tmpls)
+}
+
+// ExpandStepsWithContext injects the template for each templated
+// step in a yaml configuration and stops fetching and rendering
+// templates when the provided context is done.
+//
+// Templates may render steps that reference other templates, which
+// are expanded until the maximum depth for the compiler is reached.
+//
+// nolint: lll // ignore long line length due to variable names
+func (c *client) ExpandStepsWithContext(ctx context.Context, s *yaml.Build, tmpls map[string]*yaml.Template) (yaml.StepSlice, yaml.SecretSlice, yaml.ServiceSlice, raw.StringSliceMap, error) {
+	return c.expandSteps(ctx, s, tmpls, 1, nil)
+}
+
+// expandSteps injects the template for each templated step in a
+// yaml configuration at the depth provided. The chain contains the
+// templates rendered to produce the steps for detecting cycles.
+//
+// nolint: lll,funlen,gocyclo // ignore long line length due to variable names
+func (c *client) expandSteps(ctx context.Context, s *yaml.Build, tmpls map[string]*yaml.Template, depth int, chain []*yaml.Template) (yaml.StepSlice, yaml.SecretSlice, yaml.ServiceSlice, raw.StringSliceMap, error) {
 	steps := yaml.StepSlice{}
 	secrets := s.Secrets
 	services := s.Services
@@ -64,10 +114,29 @@ func (c *client) ExpandSteps(s *yaml.Build, tmpls map[string]*yaml.Template) (ya
 			continue
 		}
 
+		// check if the context has been canceled
+		if err := ctx.Err(); err != nil {
+			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(fmt.Errorf("unable to expand template %s for step %s: %w", step.Template.Name, step.Name, err), step, "template")
+		}
+
+		// check if the maximum depth for nested templates has been exceeded
+		if depth > c.templateDepth() {
+			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(fmt.Errorf("max template depth of %d exceeded for template %s in step %s", c.templateDepth(), step.Template.Name, step.Name), step, "template")
+		}
+
+		// capture the templated step for the origins
+		templated := step
+
 		// lookup step template name
 		tmpl, ok := tmpls[step.Template.Name]
 		if !ok {
-			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, fmt.Errorf("missing template source for template %s in pipeline for step %s", step.Template.Name, step.Name)
+			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(fmt.Errorf("missing template source for template %s in pipeline for step %s", step.Template.Name, step.Name), step, "template")
+		}
+
+		// check if the template was already rendered to produce the step
+		err := cycleTemplates(chain, tmpl)
+		if err != nil {
+			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(fmt.Errorf("%v for step %s", err, step.Name), step, "template")
 		}
 
 		// Create some default global environment inject vars
@@ -80,85 +149,136 @@ func (c *client) ExpandSteps(s *yaml.Build, tmpls map[string]*yaml.Template) (ya
 		}
 
 		// inject environment information for template
-		step, err := c.EnvironmentStep(step, envGlobalSteps)
+		step, err = c.EnvironmentStep(step, envGlobalSteps)
 		if err != nil {
-			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, err
+			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(err, step, "template")
 		}
 
+		// capture the checksum pinned for the template
+		digest := ""
+		if ext := c.extension(tmpl); ext != nil {
+			digest = ext.SHA256
+		}
+
+		// loader for the modules loaded by starlark templates
+		var loader starlark.Loader
+
 		switch {
 		case c.local:
+			loader = &localLoader{template: tmpl.Source}
+
 			a := &afero.Afero{
 				Fs: afero.NewOsFs(),
 			}
 
 			bytes, err = a.ReadFile(tmpl.Source)
 			if err != nil {
-				return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, err
+				return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(err, step, "template")
+			}
+
+		default:
+			// lookup the registry for the template type
+			svc, err := c.registry(tmpl.Type)
+			if err != nil {
+				return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(fmt.Errorf("%v for template %s in pipeline for step %s", err, tmpl.Name, step.Name), tmpl, "type")
 			}
 
-		case strings.EqualFold(tmpl.Type, "github"):
 			// parse source from template
-			src, err := c.Github.Parse(tmpl.Source)
+			src, err := svc.Parse(tmpl.Source)
 			if err != nil {
-				return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, fmt.Errorf("invalid template source provided for %s: %v", step.Template.Name, err)
+				return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(fmt.Errorf("invalid template source provided for %s: %v", step.Template.Name, err), step, "template")
 			}
 
-			// pull from github without auth when the host isn't provided or is set to github.com
-			if !c.UsePrivateGithub && (len(src.Host) == 0 || strings.Contains(src.Host, "github.com")) {
-				logrus.WithFields(logrus.Fields{
-					"org":  src.Org,
-					"repo": src.Repo,
-					"path": src.Name,
-					"host": src.Host,
-				}).Tracef("Using GitHub client to pull template")
-				bytes, err = c.Github.Template(nil, src)
-				if err != nil {
-					return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, err
-				}
-			} else {
-				logrus.WithFields(logrus.Fields{
-					"org":  src.Org,
-					"repo": src.Repo,
-					"path": src.Name,
-					"host": src.Host,
-				}).Tracef("Using authenticated GitHub client to pull template")
-				// use private (authenticated) github instance to pull from
-				bytes, err = c.PrivateGithub.Template(c.user, src)
-				if err != nil {
-					return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, err
-				}
+			logrus.WithFields(logrus.Fields{
+				"org":  src.Org,
+				"repo": src.Repo,
+				"path": src.Name,
+				"host": src.Host,
+			}).Tracef("Using %s registry to pull template", tmpl.Type)
+
+			// pull from the registry for the template type
+			bytes, err = registry.TemplateWithContext(ctx, svc, c.user, src)
+			if err != nil {
+				return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(err, step, "template")
 			}
 
-		default:
-			logrus.Errorf("Unsupported template type: %v", tmpl.Type)
-			continue
+			l := newRegistryLoader(tmpl.Type, svc, c.user, src)
+			l.pinned = len(digest) > 0
+
+			loader = l
+		}
+
+		// verify the checksum pinned for the template before rendering
+		err = verifyTemplate(tmpl.Name, bytes, digest)
+		if err != nil {
+			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(err, tmpl, "sha256")
+		}
+
+		// check if the template variables are rendered in strict mode
+		strict, err := c.strictTemplate(tmpl)
+		if err != nil {
+			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(fmt.Errorf("invalid strict value for template %s: %v", tmpl.Name, err), tmpl, "strict")
+		}
+
+		// check the lifecycle of the template declared by the metadata and
+		// validate the variables for the template against the schema
+		// declared by the template while the template is rendered
+		prepare := func(metadata *template.Metadata, schema template.Schema) (map[string]interface{}, error) {
+			err := c.checkTemplate(tmpl, metadata)
+			if err != nil {
+				return nil, err
+			}
+
+			return templateVars(tmpl, step, schema)
+		}
+
+		// capture the variables never referenced by the template as warnings
+		warn := func(message string) {
+			c.warn(&compiler.Warning{
+				Code:    compiler.WarningUnusedVariables,
+				Step:    step.Name,
+				Message: message,
+			}, step, "template")
 		}
 
-		var tmplSteps yaml.StepSlice
-		var tmplSecrets yaml.SecretSlice
-		var tmplServices yaml.ServiceSlice
-		var tmplEnvironment raw.StringSliceMap
+		var result *template.Result
 
 		// TODO: provide friendlier error messages with file type mismatches
 		switch tmpl.Format {
 		case "go", "golang", "":
 			// render template for steps
-			tmplSteps, tmplSecrets, tmplServices, tmplEnvironment, err = native.RenderStep(string(bytes), step)
+			result, err = native.RenderStepWithOptions(ctx, string(bytes), step, &native.Options{Strict: strict, Warn: warn, Prepare: prepare})
 			if err != nil {
-				return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, err
+				return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(err, step, "template")
 			}
 		case "starlark":
+			opts := c.starlarkOptions(loader)
+			opts.Strict = strict
+			opts.Warn = warn
+			opts.Prepare = prepare
+
 			// render template for steps
-			tmplSteps, tmplSecrets, tmplServices, tmplEnvironment, err = starlark.RenderStep(string(bytes), step)
+			result, err = starlark.RenderStepWithOptions(ctx, string(bytes), step, opts)
 			if err != nil {
-				return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, err
+				return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(err, step, "template")
 			}
 		default:
-			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, fmt.Errorf("format of %s is unsupported", tmpl.Format)
+			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(fmt.Errorf("format of %s is unsupported", tmpl.Format), step, "template")
 		}
 
+		tmplBuild := result.Build
+
+		// capture the keys that aren't supported by the pipeline types for the rendered template
+		_, err = c.extend(result.Raw, tmplBuild, nil)
+		if err != nil {
+			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.positions.wrap(fmt.Errorf("%v for template %s", err, tmpl.Name), step, "template")
+		}
+
+		// capture the template that introduced each rendered step, secret and service
+		c.track(&origin{template: tmpl.Name, step: templated}, tmplBuild.Steps, tmplBuild.Secrets, tmplBuild.Services)
+
 		// loop over secrets within template
-		for _, secret := range tmplSecrets {
+		for _, secret := range tmplBuild.Secrets {
 			found := false
 			// loop over secrets within base configuration
 			for _, sec := range secrets {
@@ -175,7 +295,7 @@ func (c *client) ExpandSteps(s *yaml.Build, tmpls map[string]*yaml.Template) (ya
 		}
 
 		// loop over services within template
-		for _, service := range tmplServices {
+		for _, service := range tmplBuild.Services {
 			found := false
 			for _, serv := range services {
 				if serv.Name == service.Name {
@@ -190,7 +310,7 @@ func (c *client) ExpandSteps(s *yaml.Build, tmpls map[string]*yaml.Template) (ya
 		}
 
 		// loop over environment within template
-		for key, value := range tmplEnvironment {
+		for key, value := range tmplBuild.Environment {
 			found := false
 			for env := range environment {
 				if key == env {
@@ -204,6 +324,30 @@ func (c *client) ExpandSteps(s *yaml.Build, tmpls map[string]*yaml.Template) (ya
 			}
 		}
 
+		// create map of templates for the nested templates where the
+		// templates declared by the template take precedence
+		nested := make(map[string]*yaml.Template)
+		for name, t := range tmpls {
+			nested[name] = t
+		}
+
+		for name, t := range mapFromTemplates(tmplBuild.Templates) {
+			nested[name] = t
+		}
+
+		// capture the templates rendered to produce the templated steps
+		rendered := append(append([]*yaml.Template{}, chain...), tmpl)
+
+		// inject the nested templates into the templated steps
+		tmplSteps, tmplSecrets, tmplServices, tmplEnvironment, err := c.expandSteps(ctx, &yaml.Build{Metadata: s.Metadata, Steps: tmplBuild.Steps, Secrets: secrets, Services: services, Environment: environment}, nested, depth+1, rendered)
+		if err != nil {
+			return yaml.StepSlice{}, yaml.SecretSlice{}, yaml.ServiceSlice{}, raw.StringSliceMap{}, c.wrapNested(err, templated, tmpl.Name)
+		}
+
+		secrets = tmplSecrets
+		services = tmplServices
+		environment = tmplEnvironment
+
 		// add templated steps
 		steps = append(steps, tmplSteps...)
 	}
@@ -211,6 +355,103 @@ func (c *client) ExpandSteps(s *yaml.Build, tmpls map[string]*yaml.Template) (ya
 	return steps, secrets, services, environment, nil
 }
 
+// wrapNested is a helper function that captures the template for
+// the error from expanding the nested templates of the template.
+// The error is wrapped with the location of the templated step
+// unless it already contains the location of a nested template.
+func (c *client) wrapNested(err error, step *yaml.Step, name string) error {
+	// check if the error is located in a nested template
+	if e, ok := err.(*compiler.PositionError); ok {
+		return &compiler.PositionError{
+			Position: e.Position,
+			Err:      fmt.Errorf("%w in template %s", e.Err, name),
+		}
+	}
+
+	return c.positions.wrap(fmt.Errorf("%w in template %s", err, name), step, "template")
+}
+
+// templateDepth is a helper function that returns the maximum depth for
+// nested templates, falling back to the default depth when it isn't set,
+// i.e. for a client that wasn't created with New.
+func (c *client) templateDepth() int {
+	if c.TemplateDepth <= 0 {
+		return defaultTemplateDepth
+	}
+
+	return c.TemplateDepth
+}
+
+// track is a helper function that captures the template
+// that introduced each of the rendered steps, secrets
+// and services for reporting validation problems.
+//
+// nolint: lll // ignore long line length due to parameters
+func (c *client) track(o *origin, steps yaml.StepSlice, secrets yaml.SecretSlice, services yaml.ServiceSlice) {
+	if c.origins == nil {
+		c.origins = make(map[interface{}]*origin)
+	}
+
+	for _, step := range steps {
+		c.origins[step] = o
+	}
+
+	for _, secret := range secrets {
+		c.origins[secret] = o
+	}
+
+	for _, service := range services {
+		c.origins[service] = o
+	}
+}
+
+// cycleTemplates is a helper function that verifies the template
+// was not already rendered to produce the templated step.
+func cycleTemplates(chain []*yaml.Template, tmpl *yaml.Template) error {
+	for i, t := range chain {
+		// templates are the same when they share the same type and source
+		if !strings.EqualFold(t.Type, tmpl.Type) || t.Source != tmpl.Source {
+			continue
+		}
+
+		names := []string{}
+		for _, t := range chain[i:] {
+			names = append(names, t.Name)
+		}
+
+		names = append(names, tmpl.Name)
+
+		return fmt.Errorf("template cycle detected: %s", strings.Join(names, " -> "))
+	}
+
+	return nil
+}
+
+// verifyTemplate is a helper function that verifies the
+// sha256 checksum of the template matches the digest
+// pinned for the template. The digest may be empty.
+func verifyTemplate(name string, b []byte, digest string) error {
+	// skip if no digest is pinned for the template
+	if len(digest) == 0 {
+		return nil
+	}
+
+	// check the digest is a hex encoded sha256 checksum
+	want, err := hex.DecodeString(strings.TrimPrefix(digest, "sha256:"))
+	if err != nil || len(want) != sha256.Size {
+		return fmt.Errorf("invalid sha256 checksum %s for template %s", digest, name)
+	}
+
+	sum := sha256.Sum256(b)
+
+	got := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(got, strings.TrimPrefix(digest, "sha256:")) {
+		return fmt.Errorf("sha256 checksum mismatch for template %s: got %s, want %s", name, got, digest)
+	}
+
+	return nil
+}
+
 // helper function that creates a map of templates from a yaml configuration.
 func mapFromTemplates(templates []*yaml.Template) map[string]*yaml.Template {
 	m := make(map[string]*yaml.Template)
@@ -5,12 +5,20 @@
 package native
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/go-vela/compiler/compiler"
+
+	"github.com/go-vela/types/library"
 	"github.com/go-vela/types/raw"
 	"github.com/go-vela/types/yaml"
 	"github.com/google/go-cmp/cmp"
@@ -308,6 +316,794 @@ func TestNative_ExpandSteps(t *testing.T) {
 	}
 }
 
+func TestNative_ExpandSteps_Gitlab(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	resp := httptest.NewRecorder()
+	_, engine := gin.CreateTestContext(resp)
+
+	// route on the encoded path so the project and file stay a single segment
+	engine.UseRawPath = true
+
+	// setup mock server
+	engine.GET("/api/v4/projects/foo%2Fbar%2Fbaz/repository/files/:file/raw", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.File("testdata/template.yml")
+	})
+
+	s := httptest.NewServer(engine)
+	defer s.Close()
+
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.String("gitlab-url", s.URL, "doc")
+	set.String("gitlab-token", "foobar", "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	tmpls := map[string]*yaml.Template{
+		"gradle": {
+			Name:   "gradle",
+			Source: strings.TrimPrefix(s.URL, "http://") + "/foo/bar/baz/-/template.yml@main",
+			Type:   "gitlab",
+		},
+	}
+
+	steps := yaml.StepSlice{
+		&yaml.Step{
+			Name: "sample",
+			Template: yaml.StepTemplate{
+				Name: "gradle",
+				Variables: map[string]interface{}{
+					"image":       "openjdk:latest",
+					"environment": "{ GRADLE_USER_HOME: .gradle }",
+					"pull_policy": "pull: true",
+				},
+			},
+		},
+	}
+
+	want := []string{"sample_install", "sample_test", "sample_build"}
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating new compiler returned err: %v", err)
+	}
+
+	steps, _, _, _, err = compiler.ExpandSteps(&yaml.Build{Steps: steps}, tmpls)
+	if err != nil {
+		t.Errorf("ExpandSteps returned err: %v", err)
+	}
+
+	got := []string{}
+	for _, step := range steps {
+		got = append(got, step.Name)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ExpandSteps() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNative_ExpandSteps_URL(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	resp := httptest.NewRecorder()
+	_, engine := gin.CreateTestContext(resp)
+
+	// setup mock server
+	engine.GET("/templates/gradle.yml", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.File("testdata/template.yml")
+	})
+
+	s := httptest.NewServer(engine)
+	defer s.Close()
+
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.Var(cli.NewStringSlice("127.0.0.1"), "url-hosts", "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	b, err := ioutil.ReadFile("testdata/template.yml")
+	if err != nil {
+		t.Errorf("Reading file returned err: %v", err)
+	}
+
+	sum := sha256.Sum256(b)
+
+	// setup tests
+	tests := []struct {
+		failure bool
+		digest  string
+	}{
+		{ // no digest
+			failure: false,
+			digest:  "",
+		},
+		{ // matching digest
+			failure: false,
+			digest:  hex.EncodeToString(sum[:]),
+		},
+		{ // matching digest with prefix
+			failure: false,
+			digest:  "sha256:" + hex.EncodeToString(sum[:]),
+		},
+		{ // mismatched digest
+			failure: true,
+			digest:  strings.Repeat("0", 64),
+		},
+		{ // invalid digest
+			failure: true,
+			digest:  "latest",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		config := fmt.Sprintf(`version: "1"
+templates:
+  - name: gradle
+    source: %s/templates/gradle.yml
+    type: url
+    sha256: "%s"
+steps:
+  - name: sample
+    template:
+      name: gradle
+      vars:
+        image: openjdk:latest
+        environment: "{ GRADLE_USER_HOME: .gradle }"
+        pull_policy: "pull: true"
+`, s.URL, test.digest)
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating new compiler returned err: %v", err)
+		}
+
+		p, err := compiler.Parse([]byte(config))
+		if err != nil {
+			t.Errorf("Parse returned err: %v", err)
+		}
+
+		got, _, _, _, err := compiler.ExpandSteps(p, mapFromTemplates(p.Templates))
+
+		if test.failure {
+			if err == nil {
+				t.Errorf("ExpandSteps for digest %s should have returned err", test.digest)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ExpandSteps for digest %s returned err: %v", test.digest, err)
+		}
+
+		if len(got) != 3 {
+			t.Errorf("ExpandSteps for digest %s returned %d steps, want 3", test.digest, len(got))
+		}
+	}
+}
+
+func TestNative_ExpandSteps_PinnedPipelineTypes(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	config := fmt.Sprintf(`version: "1"
+templates:
+  - name: sample
+    source: testdata/strict/template.yml
+    type: github
+    sha256: "%s"
+steps:
+  - name: sample
+    template:
+      name: sample
+      vars:
+        image: alpine
+        extra: foo
+`, strings.Repeat("0", 64))
+
+	// setup tests
+	tests := []struct {
+		pipelineType string
+		config       string
+	}{
+		{
+			pipelineType: "yaml",
+			config:       config,
+		},
+		{
+			pipelineType: "go",
+			config:       config,
+		},
+		{
+			pipelineType: "starlark",
+			config: fmt.Sprintf(`
+def main(ctx):
+    return {
+        "version": "1",
+        "templates": [
+            {"name": "sample", "source": "testdata/strict/template.yml", "type": "github", "sha256": "%s"},
+        ],
+        "steps": [
+            {"name": "sample", "template": {"name": "sample", "vars": {"image": "alpine", "extra": "foo"}}},
+        ],
+    }
+`, strings.Repeat("0", 64)),
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		pipelineType := test.pipelineType
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating new compiler returned err: %v", err)
+		}
+
+		compiler.WithLocal(true)
+		compiler.WithRepo(&library.Repo{PipelineType: &pipelineType})
+
+		p, err := compiler.Parse([]byte(test.config))
+		if err != nil {
+			t.Errorf("Parse for %s returned err: %v", test.pipelineType, err)
+
+			continue
+		}
+
+		_, _, _, _, err = compiler.ExpandSteps(p, mapFromTemplates(p.Templates))
+		if err == nil || !strings.Contains(err.Error(), "sha256 checksum mismatch for template sample") {
+			t.Errorf("ExpandSteps for %s returned err %v, want sha256 checksum mismatch", test.pipelineType, err)
+		}
+	}
+}
+
+func TestNative_ExpandSteps_Strict(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	// setup tests
+	tests := []struct {
+		name         string
+		pipelineType string
+		global       bool
+		strict       string
+		wantErr      string
+	}{
+		{
+			name: "lenient",
+		},
+		{
+			name:    "compiler strict",
+			global:  true,
+			wantErr: "template sample has unused variables: extra",
+		},
+		{
+			name:   "template lenient",
+			global: true,
+			strict: "false",
+		},
+		{
+			name:    "template strict",
+			strict:  "true",
+			wantErr: "template sample has unused variables: extra",
+		},
+		{
+			name:         "go pipeline strict",
+			pipelineType: "go",
+			strict:       "true",
+			wantErr:      "template sample has unused variables: extra",
+		},
+		{
+			name:    "invalid",
+			strict:  "bla",
+			wantErr: "invalid strict value for template sample",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		strict := ""
+		if len(test.strict) > 0 {
+			strict = "\n    strict: " + test.strict
+		}
+
+		config := fmt.Sprintf(`version: "1"
+templates:
+  - name: sample
+    source: testdata/strict/template.yml
+    type: github%s
+steps:
+  - name: sample
+    template:
+      name: sample
+      vars:
+        image: alpine
+        extra: foo
+`, strict)
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating new compiler returned err: %v", err)
+		}
+
+		compiler.WithLocal(true)
+		compiler.TemplateStrict = test.global
+
+		if len(test.pipelineType) > 0 {
+			compiler.WithRepo(&library.Repo{PipelineType: &test.pipelineType})
+		}
+
+		p, err := compiler.Parse([]byte(config))
+		if err != nil {
+			t.Errorf("Parse for %s returned err: %v", test.name, err)
+		}
+
+		_, _, _, _, err = compiler.ExpandSteps(p, mapFromTemplates(p.Templates))
+
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ExpandSteps for %s returned err %v, want %s", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ExpandSteps for %s returned err: %v", test.name, err)
+		}
+	}
+}
+
+func TestNative_ExpandSteps_Nested(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	// setup tests
+	tests := []struct {
+		name    string
+		source  string
+		depth   int
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "nested",
+			source: "testdata/nested/outer.yml",
+			depth:  2,
+			want:   []string{"sample_build", "sample_nested_test"},
+		},
+		{
+			name:   "default depth",
+			source: "testdata/nested/outer.yml",
+			depth:  0,
+			want:   []string{"sample_build", "sample_nested_test"},
+		},
+		{
+			name:    "max depth",
+			source:  "testdata/nested/outer.yml",
+			depth:   1,
+			wantErr: "max template depth of 1 exceeded for template inner in step sample_nested",
+		},
+		{
+			name:    "pinned",
+			source:  "testdata/nested/pinned.yml",
+			depth:   2,
+			wantErr: "sha256 checksum mismatch for template inner",
+		},
+		{
+			name:    "cycle",
+			source:  "testdata/nested/cycle.yml",
+			depth:   5,
+			wantErr: "template cycle detected: outer -> self for step sample_again",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		tmpls := map[string]*yaml.Template{
+			"outer": {
+				Name:   "outer",
+				Source: test.source,
+				Type:   "github",
+			},
+		}
+
+		steps := yaml.StepSlice{
+			&yaml.Step{
+				Name: "sample",
+				Template: yaml.StepTemplate{
+					Name: "outer",
+					Variables: map[string]interface{}{
+						"image": "alpine",
+					},
+				},
+			},
+		}
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating new compiler returned err: %v", err)
+		}
+
+		compiler.WithLocal(true)
+		compiler.TemplateDepth = test.depth
+
+		got, _, _, _, err := compiler.ExpandSteps(&yaml.Build{Steps: steps}, tmpls)
+
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ExpandSteps for %s returned err %v, want %s", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ExpandSteps for %s returned err: %v", test.name, err)
+		}
+
+		names := []string{}
+		for _, step := range got {
+			names = append(names, step.Name)
+		}
+
+		if diff := cmp.Diff(test.want, names); diff != "" {
+			t.Errorf("ExpandSteps for %s mismatch (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestNative_ExpandSteps_NestedPosition(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	config := `version: "1"
+
+templates:
+  - name: outer
+    source: testdata/nested/shared.yml
+    type: github
+  - name: inner
+    source: testdata/nested/inner.yml
+    type: github
+    sha256: "0000000000000000000000000000000000000000000000000000000000000000"
+
+steps:
+  - name: sample
+    template:
+      name: outer
+      vars:
+        image: alpine
+`
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating new compiler returned err: %v", err)
+	}
+
+	compiler.WithLocal(true)
+
+	p, err := compiler.Parse([]byte(config))
+	if err != nil {
+		t.Errorf("Parse returned err: %v", err)
+	}
+
+	_, _, _, _, err = compiler.ExpandSteps(p, mapFromTemplates(p.Templates))
+	if err == nil {
+		t.Errorf("ExpandSteps should have returned err")
+
+		return
+	}
+
+	// the error is located once at the innermost template
+	if !strings.HasPrefix(err.Error(), "line 10, column 5: sha256 checksum mismatch for template inner") {
+		t.Errorf("ExpandSteps returned err %v, want location of inner template", err)
+	}
+
+	if strings.Count(err.Error(), "line ") != 1 || !strings.HasSuffix(err.Error(), " in template outer") {
+		t.Errorf("ExpandSteps returned err %v, want single location in template outer", err)
+	}
+}
+
+func TestNative_ExpandSteps_Load(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	// setup tests
+	tests := []struct {
+		name    string
+		source  string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "load",
+			source: "testdata/load/template.star",
+			want:   []string{"sample_build", "sample_test"},
+		},
+		{
+			name:    "cycle",
+			source:  "testdata/load/cycle.star",
+			wantErr: "cycle detected in load: testdata/load/lib/a.star -> testdata/load/lib/b.star -> testdata/load/lib/a.star",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		tmpls := map[string]*yaml.Template{
+			"helpers": {
+				Name:   "helpers",
+				Source: test.source,
+				Format: "starlark",
+				Type:   "github",
+			},
+		}
+
+		steps := yaml.StepSlice{
+			&yaml.Step{
+				Name: "sample",
+				Template: yaml.StepTemplate{
+					Name: "helpers",
+					Variables: map[string]interface{}{
+						"image": "alpine",
+					},
+				},
+			},
+		}
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating new compiler returned err: %v", err)
+		}
+
+		compiler.WithLocal(true)
+
+		got, _, _, _, err := compiler.ExpandSteps(&yaml.Build{Steps: steps}, tmpls)
+
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ExpandSteps for %s returned err %v, want %s", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ExpandSteps for %s returned err: %v", test.name, err)
+		}
+
+		names := []string{}
+		for _, step := range got {
+			names = append(names, step.Name)
+		}
+
+		if diff := cmp.Diff(test.want, names); diff != "" {
+			t.Errorf("ExpandSteps for %s mismatch (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestNative_ExpandSteps_Schema(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	// setup tests
+	tests := []struct {
+		name    string
+		source  string
+		format  string
+		vars    map[string]interface{}
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "go defaults",
+			source: "testdata/schema/template.yml",
+			format: "go",
+			vars:   map[string]interface{}{"image": "alpine"},
+			want:   "alpine:latest",
+		},
+		{
+			name:   "go",
+			source: "testdata/schema/template.yml",
+			format: "go",
+			vars:   map[string]interface{}{"image": "alpine", "tag": "3.14"},
+			want:   "alpine:3.14",
+		},
+		{
+			name:    "go invalid type",
+			source:  "testdata/schema/template.yml",
+			format:  "go",
+			vars:    map[string]interface{}{"image": "alpine", "tag": 3.14},
+			wantErr: "invalid variables for template sample: variable `tag` must be a string",
+		},
+		{
+			name:    "go missing",
+			source:  "testdata/schema/template.yml",
+			format:  "go",
+			vars:    map[string]interface{}{"tag": "3.14"},
+			wantErr: "invalid variables for template sample: variable `image` is required",
+		},
+		{
+			name:   "starlark defaults",
+			source: "testdata/schema/template.star",
+			format: "starlark",
+			vars:   map[string]interface{}{"image": "alpine"},
+			want:   "alpine:latest",
+		},
+		{
+			name:    "starlark undeclared",
+			source:  "testdata/schema/template.star",
+			format:  "starlark",
+			vars:    map[string]interface{}{"image": "alpine", "tga": "3.14"},
+			wantErr: "invalid variables for template sample: variable `tga` is not declared by the template",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		tmpls := map[string]*yaml.Template{
+			"sample": {
+				Name:   "sample",
+				Source: test.source,
+				Format: test.format,
+				Type:   "github",
+			},
+		}
+
+		steps := yaml.StepSlice{
+			&yaml.Step{
+				Name: "sample",
+				Template: yaml.StepTemplate{
+					Name:      "sample",
+					Variables: test.vars,
+				},
+			},
+		}
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating new compiler returned err: %v", err)
+		}
+
+		compiler.WithLocal(true)
+
+		got, _, _, _, err := compiler.ExpandSteps(&yaml.Build{Steps: steps}, tmpls)
+
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ExpandSteps for %s returned err %v, want %s", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ExpandSteps for %s returned err: %v", test.name, err)
+		}
+
+		if len(got) != 1 || got[0].Image != test.want {
+			t.Errorf("ExpandSteps for %s is %v, want step with image %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNative_ExpandSteps_Metadata(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	// setup tests
+	tests := []struct {
+		name    string
+		source  string
+		format  string
+		version string
+		want    []*compiler.Warning
+		wantErr string
+	}{
+		{
+			name:   "go deprecated",
+			source: "testdata/metadata/deprecated.yml",
+			format: "go",
+			want: []*compiler.Warning{
+				{
+					Code:    compiler.WarningTemplateDeprecated,
+					Message: "template sample (version 1.0.0) is deprecated, use testdata/metadata/current.yml instead",
+				},
+			},
+		},
+		{
+			name:   "starlark deprecated",
+			source: "testdata/metadata/deprecated.star",
+			format: "starlark",
+			want: []*compiler.Warning{
+				{
+					Code:    compiler.WarningTemplateDeprecated,
+					Message: "template sample is deprecated",
+				},
+			},
+		},
+		{
+			name:    "compatible",
+			source:  "testdata/metadata/minimum.yml",
+			format:  "go",
+			version: "v0.11.0",
+		},
+		{
+			name:    "incompatible",
+			source:  "testdata/metadata/minimum.yml",
+			format:  "go",
+			version: "v0.10.0",
+			wantErr: "template sample requires compiler version 0.11.0 or later (current version is 0.10.0)",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		tmpls := map[string]*yaml.Template{
+			"sample": {
+				Name:   "sample",
+				Source: test.source,
+				Format: test.format,
+				Type:   "github",
+			},
+		}
+
+		// the same template in several steps only captures one warning
+		steps := yaml.StepSlice{
+			&yaml.Step{
+				Name:     "first",
+				Template: yaml.StepTemplate{Name: "sample"},
+			},
+			&yaml.Step{
+				Name:     "second",
+				Template: yaml.StepTemplate{Name: "sample"},
+			},
+		}
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating new compiler returned err: %v", err)
+		}
+
+		compiler.WithLocal(true)
+		compiler.Version = test.version
+
+		_, _, _, _, err = compiler.ExpandSteps(&yaml.Build{Steps: steps}, tmpls)
+
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ExpandSteps for %s returned err %v, want %s", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ExpandSteps for %s returned err: %v", test.name, err)
+		}
+
+		if diff := cmp.Diff(test.want, compiler.Warnings()); diff != "" {
+			t.Errorf("Warnings for %s mismatch (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
 func TestNative_ExpandStepsMulti(t *testing.T) {
 	// setup context
 	gin.SetMode(gin.TestMode)
@@ -0,0 +1,183 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"fmt"
+
+	types "github.com/go-vela/types/yaml"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// extension represents the keys declared for a step, stage
+// or template in a yaml configuration that aren't supported
+// by the pipeline types.
+//
+// The extensions are captured for every yaml configuration
+// produced while compiling, i.e. the pipeline for every
+// pipeline type, the included files and the rendered templates.
+type extension struct {
+	// SHA256 is the checksum pinned for a template.
+	SHA256 string
+	// Strict is the strict mode declared for a template.
+	Strict string
+	// Matrix is the matrix declared for a step or stage.
+	Matrix *yaml3.Node
+	// pos contains the locations of the nodes for the yaml
+	// configuration declaring the keys, or nil when the yaml
+	// configuration was rendered, i.e. by a template.
+	pos *positions
+}
+
+// wrapNode returns the error with the location of the node,
+// or the original error if the node can't be located.
+func (e *extension) wrapNode(err error, n *yaml3.Node) error {
+	if e == nil || e.pos == nil {
+		return err
+	}
+
+	return e.pos.wrapNode(err, n)
+}
+
+// newExtension is a helper function that
+// captures the keys declared by the node.
+func newExtension(n *yaml3.Node, pos *positions) *extension {
+	return &extension{
+		SHA256: scalarValue(mappingValue(n, "sha256")),
+		Strict: scalarValue(mappingValue(n, "strict")),
+		Matrix: mappingValue(n, "matrix"),
+		pos:    pos,
+	}
+}
+
+// extend captures the keys that aren't supported by the pipeline types for
+// the parsed steps, stages and templates from the bytes of the yaml
+// configuration. The positions may be nil when the yaml configuration
+// was rendered, i.e. by a template. It returns the root node of the
+// yaml configuration, or nil if the bytes can't be parsed.
+//
+// nolint: gocyclo // ignore cyclomatic complexity
+func (c *client) extend(b []byte, p *types.Build, pos *positions) (*yaml3.Node, error) {
+	doc := new(yaml3.Node)
+
+	// parse the bytes into a tree of yaml nodes
+	err := yaml3.Unmarshal(b, doc)
+	if err != nil || len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := resolve(doc.Content[0])
+
+	if c.extensions == nil {
+		c.extensions = make(map[interface{}]*extension)
+	}
+
+	// capture the keys for the parsed steps
+	c.extendSteps(mappingValue(root, "steps"), p.Steps, pos)
+
+	// capture the keys for the parsed templates
+	//
+	// templates must be captured to verify the pinned checksums
+	// and to render the templates with the declared strict mode
+	if n := mappingValue(root, "templates"); n != nil {
+		if len(n.Content) != len(p.Templates) {
+			return nil, fmt.Errorf("unable to capture the sha256 and strict keys for the templates")
+		}
+
+		for i, tmpl := range p.Templates {
+			c.extensions[tmpl] = newExtension(resolve(n.Content[i]), pos)
+		}
+	}
+
+	// capture the keys for the parsed stages
+	//
+	// stages are declared as a map in the yaml configuration
+	// so the content contains a key and value for each stage
+	if n := mappingValue(root, "stages"); n != nil {
+		for _, stage := range p.Stages {
+			node := mappingValue(n, stage.Name)
+			if node == nil {
+				continue
+			}
+
+			c.extensions[stage] = newExtension(node, pos)
+
+			c.extendSteps(mappingValue(node, "steps"), stage.Steps, pos)
+		}
+	}
+
+	return root, nil
+}
+
+// extendSteps is a helper function that captures the
+// keys that aren't supported for the parsed steps.
+func (c *client) extendSteps(n *yaml3.Node, s types.StepSlice, pos *positions) {
+	if n == nil || len(n.Content) != len(s) {
+		return
+	}
+
+	for i, step := range s {
+		c.extensions[step] = newExtension(resolve(n.Content[i]), pos)
+	}
+}
+
+// extension returns the keys that aren't supported by the
+// pipeline types captured for the parsed object, or nil if
+// no keys were captured, i.e. for the copies of a matrix.
+func (c *client) extension(v interface{}) *extension {
+	return c.extensions[v]
+}
+
+// carry is a helper function that captures the keys that aren't
+// supported by the pipeline types for the steps and stages of the
+// modified pipeline from the steps and stages with the same name
+// in the original pipeline, i.e. after an external modification.
+func (c *client) carry(original, modified *types.Build) {
+	if c.extensions == nil || original == nil || modified == nil {
+		return
+	}
+
+	carrySteps := func(from, to types.StepSlice) {
+		for _, step := range to {
+			for _, s := range from {
+				if e, ok := c.extensions[s]; ok && s.Name == step.Name {
+					c.extensions[step] = e
+				}
+			}
+		}
+	}
+
+	carrySteps(original.Steps, modified.Steps)
+
+	for _, stage := range modified.Stages {
+		for _, s := range original.Stages {
+			if s.Name != stage.Name {
+				continue
+			}
+
+			if e, ok := c.extensions[s]; ok {
+				c.extensions[stage] = e
+			}
+
+			carrySteps(s.Steps, stage.Steps)
+		}
+	}
+}
+
+// scalarValue is a helper function that returns the value of the scalar
+// node, the tag of the node when it isn't a scalar, i.e. !!seq, so the
+// value is reported as invalid, or empty when the node doesn't exist.
+func scalarValue(n *yaml3.Node) string {
+	if n == nil {
+		return ""
+	}
+
+	if n.Kind != yaml3.ScalarNode {
+		return n.Tag
+	}
+
+	return n.Value
+}
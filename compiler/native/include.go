@@ -0,0 +1,276 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/go-vela/compiler/registry"
+
+	"github.com/go-vela/types/library"
+	"github.com/go-vela/types/raw"
+	types "github.com/go-vela/types/yaml"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// include represents a partial yaml configuration
+// declared by the includes key of the pipeline.
+//
+//	includes:
+//	  - .vela/backend.yml
+//	  - source: github.com/octocat/pipelines/frontend.yml@v1
+//	    type: github
+type include struct {
+	// Source is the path to the file in the repo for the pipeline
+	// or the source of the file in the registry for the type.
+	Source string `yaml:"source"`
+	// Type is the type of the registry for the file,
+	// or empty when the file is in the repo for the pipeline.
+	Type string `yaml:"type"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface
+// for the include type to support providing only the source.
+func (i *include) UnmarshalYAML(n *yaml3.Node) error {
+	if n.Kind == yaml3.ScalarNode {
+		i.Source = n.Value
+
+		return nil
+	}
+
+	// alias the type to avoid calling UnmarshalYAML recursively
+	type alias include
+
+	return n.Decode((*alias)(i))
+}
+
+// includeConfigs merges the partial yaml configurations declared by the
+// includes key into the pipeline. The files are merged in the order they
+// are declared and the steps, stages, services, secrets and templates
+// are added after the ones declared by the pipeline. The names must be
+// unique across the pipeline and the included files. The environment
+// declared by the pipeline overrides the environment of the included
+// files, while included files must not declare different values for
+// the same variable. The version, metadata and worker of the included
+// files are ignored and included files can't include other files.
+//
+// nolint: funlen // ignore function length due to comments
+func (c *client) includeConfigs(ctx context.Context, p *types.Build, n *yaml3.Node) error {
+	if n.Kind != yaml3.SequenceNode {
+		return c.positions.wrapNode(fmt.Errorf("includes must be a list of files"), n)
+	}
+
+	// sources for the environment variables declared by the included files
+	env := make(map[string]string)
+
+	for _, item := range n.Content {
+		// check if the context has been canceled
+		if err := ctx.Err(); err != nil {
+			return c.positions.wrapNode(fmt.Errorf("unable to include files: %w", err), item)
+		}
+
+		inc := new(include)
+
+		err := item.Decode(inc)
+		if err != nil || len(inc.Source) == 0 {
+			return c.positions.wrapNode(fmt.Errorf("invalid include, must provide a source"), item)
+		}
+
+		file, b, err := c.includeSource(ctx, inc)
+		if err != nil {
+			return c.positions.wrapNode(fmt.Errorf("unable to include %s: %w", inc.Source, err), item)
+		}
+
+		included, err := ParseBytes(b)
+		if err != nil {
+			return newPositions(file, b, nil).wrapLine(fmt.Errorf("unable to include %s: %w", inc.Source, err))
+		}
+
+		pos := newPositions(file, b, included)
+
+		// check if the included file includes other files
+		if k := mappingKey(pos.root, "includes"); k != nil {
+			return pos.wrapNode(fmt.Errorf("nested includes are not supported in %s", inc.Source), k)
+		}
+
+		// capture the keys that aren't supported by the pipeline types in the included file
+		_, err = c.extend(b, included, pos)
+		if err != nil {
+			return pos.wrapNode(fmt.Errorf("unable to include %s: %w", inc.Source, err), pos.root)
+		}
+
+		err = mergeConfig(p, included, inc.Source, env)
+		if err != nil {
+			return c.positions.wrapNode(err, item)
+		}
+
+		// capture the locations of the nodes in the included file
+		c.positions.merge(pos)
+	}
+
+	return nil
+}
+
+// includeSource is a helper function that captures the name
+// and contents of the file for the include. Files in the repo
+// for the pipeline are read from the local filesystem relative
+// to the including file for a local pipeline, or from the commit
+// for the build on the scm host for the repo otherwise.
+func (c *client) includeSource(ctx context.Context, inc *include) (string, []byte, error) {
+	// check if the file is in the repo for the pipeline
+	if len(inc.Type) == 0 {
+		if c.local {
+			a := &afero.Afero{
+				Fs: afero.NewOsFs(),
+			}
+
+			file := inc.Source
+
+			// resolve the file relative to the directory of the including file
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(c.positions.file), file)
+			}
+
+			b, err := a.ReadFile(file)
+
+			return file, b, err
+		}
+
+		host, err := repoHost(c.repo)
+		if err != nil {
+			return "", nil, err
+		}
+
+		svc, err := c.registry("github")
+		if err != nil {
+			return "", nil, err
+		}
+
+		src := &registry.Source{
+			Host: host,
+			Org:  c.repo.GetOrg(),
+			Repo: c.repo.GetName(),
+			Name: inc.Source,
+			Ref:  c.build.GetCommit(),
+		}
+
+		logrus.Tracef("Using %s registry to pull included file %s from %s", "github", inc.Source, host)
+
+		b, err := registry.TemplateWithContext(ctx, svc, c.user, src)
+
+		return inc.Source, b, err
+	}
+
+	// lookup the registry for the include type
+	svc, err := c.registry(inc.Type)
+	if err != nil {
+		return "", nil, err
+	}
+
+	src, err := svc.Parse(inc.Source)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid source: %w", err)
+	}
+
+	logrus.Tracef("Using %s registry to pull included file %s", inc.Type, inc.Source)
+
+	b, err := registry.TemplateWithContext(ctx, svc, c.user, src)
+
+	return inc.Source, b, err
+}
+
+// repoHost is a helper function that returns the host of
+// the scm for the repo from the clone or link for the repo.
+func repoHost(r *library.Repo) (string, error) {
+	for _, address := range []string{r.GetClone(), r.GetLink()} {
+		u, err := url.Parse(address)
+		if err == nil && len(u.Host) > 0 {
+			return u.Host, nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to determine the scm host for repo %s/%s", r.GetOrg(), r.GetName())
+}
+
+// mergeConfig is a helper function that merges the partial yaml
+// configuration into the pipeline. The env contains the included
+// file that declared each environment variable from an include.
+//
+// nolint: gocyclo // ignore cyclomatic complexity
+func mergeConfig(p, inc *types.Build, name string, env map[string]string) error {
+	for _, step := range inc.Steps {
+		for _, s := range p.Steps {
+			if s.Name == step.Name {
+				return fmt.Errorf("step %s from include %s is already declared", step.Name, name)
+			}
+		}
+	}
+
+	for _, stage := range inc.Stages {
+		for _, s := range p.Stages {
+			if s.Name == stage.Name {
+				return fmt.Errorf("stage %s from include %s is already declared", stage.Name, name)
+			}
+		}
+	}
+
+	for _, service := range inc.Services {
+		for _, s := range p.Services {
+			if s.Name == service.Name {
+				return fmt.Errorf("service %s from include %s is already declared", service.Name, name)
+			}
+		}
+	}
+
+	for _, secret := range inc.Secrets {
+		for _, s := range p.Secrets {
+			if s.Name == secret.Name {
+				return fmt.Errorf("secret %s from include %s is already declared", secret.Name, name)
+			}
+		}
+	}
+
+	for _, tmpl := range inc.Templates {
+		for _, t := range p.Templates {
+			if t.Name == tmpl.Name {
+				return fmt.Errorf("template %s from include %s is already declared", tmpl.Name, name)
+			}
+		}
+	}
+
+	if p.Environment == nil && len(inc.Environment) > 0 {
+		p.Environment = make(raw.StringSliceMap)
+	}
+
+	for k, v := range inc.Environment {
+		existing, ok := p.Environment[k]
+		if !ok {
+			p.Environment[k] = v
+			env[k] = name
+
+			continue
+		}
+
+		// check if another included file declared a different value
+		if source, ok := env[k]; ok && existing != v {
+			return fmt.Errorf("environment variable %s from include %s conflicts with include %s", k, name, source)
+		}
+	}
+
+	p.Steps = append(p.Steps, inc.Steps...)
+	p.Stages = append(p.Stages, inc.Stages...)
+	p.Services = append(p.Services, inc.Services...)
+	p.Secrets = append(p.Secrets, inc.Secrets...)
+	p.Templates = append(p.Templates, inc.Templates...)
+
+	return nil
+}
@@ -0,0 +1,317 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"encoding/base64"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-vela/compiler/compiler"
+
+	"github.com/go-vela/types/library"
+	"github.com/go-vela/types/raw"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-cmp/cmp"
+	"github.com/urfave/cli/v2"
+)
+
+func TestNative_Parse_Includes(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	wantSteps := []string{"lint", "backend", "frontend"}
+
+	wantEnv := raw.StringSliceMap{
+		"REGION":   "us-east-1",
+		"TEAM":     "backend",
+		"NODE_ENV": "test",
+	}
+
+	wantPosition := &compiler.Position{File: "testdata/includes/backend.yml", Line: 13, Column: 5}
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.WithLocal(true)
+
+	// run test
+	got, err := compiler.Parse("testdata/includes/.vela.yml")
+	if err != nil {
+		t.Errorf("Parse returned err: %v", err)
+	}
+
+	steps := []string{}
+	for _, step := range got.Steps {
+		steps = append(steps, step.Name)
+	}
+
+	if diff := cmp.Diff(wantSteps, steps); diff != "" {
+		t.Errorf("Parse steps mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(got.Services) != 1 || got.Services[0].Name != "postgres" {
+		t.Errorf("Parse services are %v, want postgres", got.Services)
+	}
+
+	if diff := cmp.Diff(wantEnv, got.Environment); diff != "" {
+		t.Errorf("Parse environment mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(wantPosition, compiler.positions.position(got.Steps[1], "image")); diff != "" {
+		t.Errorf("Parse position mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNative_Parse_Includes_Invalid(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	// setup tests
+	tests := []struct {
+		name     string
+		includes string
+		wantErr  string
+	}{
+		{
+			name:     "not a list",
+			includes: "testdata/includes/backend.yml",
+			wantErr:  "line 3, column 11: includes must be a list of files",
+		},
+		{
+			name:     "no source",
+			includes: "\n  - type: github",
+			wantErr:  "line 4, column 5: invalid include, must provide a source",
+		},
+		{
+			name:     "missing",
+			includes: "\n  - testdata/includes/missing.yml",
+			wantErr:  "unable to include testdata/includes/missing.yml",
+		},
+		{
+			name:     "unsupported type",
+			includes: "\n  - source: foo/bar/baz.yml\n    type: bitbucket",
+			wantErr:  `unable to include foo/bar/baz.yml: unsupported template type "bitbucket"`,
+		},
+		{
+			name:     "duplicate step",
+			includes: "\n  - testdata/includes/duplicate.yml",
+			wantErr:  "step lint from include testdata/includes/duplicate.yml is already declared",
+		},
+		{
+			name:     "environment conflict",
+			includes: "\n  - testdata/includes/backend.yml\n  - testdata/includes/conflict.yml",
+			wantErr:  "environment variable TEAM from include testdata/includes/conflict.yml conflicts with include testdata/includes/backend.yml",
+		},
+		{
+			name:     "nested",
+			includes: "\n  - testdata/includes/nested.yml",
+			wantErr:  "testdata/includes/nested.yml:1:1: nested includes are not supported in testdata/includes/nested.yml",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		config := `version: "1"
+
+includes: ` + test.includes + `
+
+steps:
+  - name: lint
+    image: alpine
+    commands:
+      - echo lint
+`
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating compiler returned err: %v", err)
+		}
+
+		compiler.WithLocal(true)
+
+		_, err = compiler.Parse([]byte(config))
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("Parse for %s returned err %v, want %s", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestNative_Parse_Includes_Registry(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	resp := httptest.NewRecorder()
+	_, engine := gin.CreateTestContext(resp)
+
+	// setup mock server
+	engine.GET("/api/v3/repos/:org/:repo/contents/*path", func(c *gin.Context) {
+		path := strings.TrimPrefix(c.Param("path"), "/")
+
+		// files in the repo for the pipeline are pulled from the commit for the build
+		if c.Param("org") == "octocat" && c.Param("repo") == "hello-world" && c.Query("ref") != "48afb5bdc41ad69bf22588491333f7cf71135163" {
+			c.Status(http.StatusNotFound)
+
+			return
+		}
+
+		b, err := ioutil.ReadFile("testdata/includes/" + path)
+		if err != nil {
+			c.Status(http.StatusNotFound)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"type":     "file",
+			"encoding": "base64",
+			"name":     path,
+			"path":     path,
+			"content":  base64.StdEncoding.EncodeToString(b),
+		})
+	})
+
+	s := httptest.NewServer(engine)
+	defer s.Close()
+
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.Bool("github-driver", true, "doc")
+	set.String("github-url", s.URL, "doc")
+	set.String("github-token", "", "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	r := new(library.Repo)
+	r.SetOrg("octocat")
+	r.SetName("hello-world")
+	r.SetClone(s.URL + "/octocat/hello-world.git")
+
+	b := new(library.Build)
+	b.SetCommit("48afb5bdc41ad69bf22588491333f7cf71135163")
+
+	config := `version: "1"
+
+includes:
+  - backend.yml
+  - source: github.example.com/platform/pipelines/frontend.yml
+    type: github
+
+steps:
+  - name: lint
+    image: alpine
+    commands:
+      - echo lint
+`
+
+	want := []string{"lint", "backend", "frontend"}
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.WithRepo(r).WithBuild(b)
+
+	// run test
+	got, err := compiler.Parse([]byte(config))
+	if err != nil {
+		t.Errorf("Parse returned err: %v", err)
+	}
+
+	steps := []string{}
+	for _, step := range got.Steps {
+		steps = append(steps, step.Name)
+	}
+
+	if diff := cmp.Diff(want, steps); diff != "" {
+		t.Errorf("Parse steps mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNative_Parse_Includes_NoHost(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.Bool("github-driver", true, "doc")
+	set.String("github-url", "https://github.example.com", "doc")
+	set.String("github-token", "", "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	r := new(library.Repo)
+	r.SetOrg("octocat")
+	r.SetName("hello-world")
+
+	config := `version: "1"
+
+includes:
+  - backend.yml
+
+steps:
+  - name: lint
+    image: alpine
+    commands:
+      - echo lint
+`
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.WithRepo(r)
+
+	// run test
+	_, err = compiler.Parse([]byte(config))
+	if err == nil || !strings.Contains(err.Error(), "unable to determine the scm host for repo octocat/hello-world") {
+		t.Errorf("Parse returned err %v, want unable to determine the scm host", err)
+	}
+}
+
+func TestNative_Parse_Includes_PipelineTypes(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	// setup tests
+	tests := []struct {
+		pipelineType string
+		config       string
+	}{
+		{
+			pipelineType: "go",
+			config:       "version: \"1\"\nincludes:\n  - backend.yml\nsteps: []\n",
+		},
+		{
+			pipelineType: "starlark",
+			config:       "def main(ctx):\n    return {\"version\": \"1\", \"includes\": [\"backend.yml\"], \"steps\": []}\n",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		pipelineType := test.pipelineType
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating compiler returned err: %v", err)
+		}
+
+		compiler.WithLocal(true)
+		compiler.WithRepo(&library.Repo{PipelineType: &pipelineType})
+
+		_, err = compiler.Parse([]byte(test.config))
+		if err == nil || !strings.Contains(err.Error(), "includes are not supported for "+test.pipelineType+" pipelines") {
+			t.Errorf("Parse for %s returned err %v, want includes are not supported", test.pipelineType, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-vela/compiler/registry"
+	"github.com/go-vela/compiler/template/starlark"
+
+	"github.com/go-vela/types/library"
+
+	"github.com/spf13/afero"
+)
+
+// modules is a helper function that returns the modules loaded by
+// Starlark templates, cached for the current yaml configuration.
+func (c *client) modules() *starlark.Modules {
+	if c.loaded == nil {
+		c.loaded = starlark.NewModules()
+	}
+
+	return c.loaded
+}
+
+// starlarkOptions is a helper function that returns the options for rendering
+// Starlark templates with the limits and modules for the current configuration.
+func (c *client) starlarkOptions(loader starlark.Loader) *starlark.Options {
+	return &starlark.Options{
+		Loader:            loader,
+		Modules:           c.modules(),
+		MaxExecutionSteps: c.Starlark.ExecLimit,
+		Timeout:           c.Starlark.Timeout,
+		MaxOutputSize:     c.Starlark.OutputLimit,
+	}
+}
+
+// localLoader represents a Starlark loader that
+// captures the modules from the local filesystem.
+type localLoader struct {
+	// template is the path to the template loading modules
+	template string
+}
+
+// Resolve returns the path to the module relative to the
+// directory of the template or module loading it.
+func (l *localLoader) Resolve(from, module string) (string, error) {
+	if len(from) == 0 {
+		from = l.template
+	}
+
+	if filepath.IsAbs(module) {
+		return filepath.Clean(module), nil
+	}
+
+	return filepath.Join(filepath.Dir(from), module), nil
+}
+
+// Source reads the module from the local filesystem.
+func (l *localLoader) Source(ctx context.Context, id string) ([]byte, error) {
+	a := &afero.Afero{
+		Fs: afero.NewOsFs(),
+	}
+
+	return a.ReadFile(id)
+}
+
+// commitRegex matches the refs that pin a commit.
+var commitRegex = regexp.MustCompile(`^([0-9a-fA-F]{40}|[0-9a-fA-F]{64})$`)
+
+// registryLoader represents a Starlark loader that captures
+// the modules from the registry used for the template.
+type registryLoader struct {
+	// kind is the type of template for the registry
+	kind string
+	svc  registry.Service
+	user *library.User
+	// pinned is true when the checksum for the template is pinned
+	// so the modules must be captured from an immutable commit
+	pinned bool
+	// sources contains the registry source for each resolved module
+	// with the source for the template stored under an empty ID
+	sources map[string]*registry.Source
+}
+
+// newRegistryLoader returns a Starlark loader that captures modules from the
+// registry relative to the provided source for the template loading them.
+//
+// nolint: lll // ignore long line length due to parameters
+func newRegistryLoader(kind string, svc registry.Service, u *library.User, src *registry.Source) *registryLoader {
+	return &registryLoader{
+		kind:    strings.ToLower(kind),
+		svc:     svc,
+		user:    u,
+		sources: map[string]*registry.Source{"": src},
+	}
+}
+
+// Resolve returns the ID for the module in the same repository and at the
+// same ref as the template or module loading it. The module path is relative
+// to the directory of the loading module unless it starts with a "/". Modules
+// for templates captured from a full url must have the same scheme and host.
+//
+// nolint: funlen // ignore function length due to comments
+func (l *registryLoader) Resolve(from, module string) (string, error) {
+	base, ok := l.sources[from]
+	if !ok {
+		return "", fmt.Errorf("unknown module %s", from)
+	}
+
+	src := &registry.Source{
+		Host: base.Host,
+		Org:  base.Org,
+		Repo: base.Repo,
+		Ref:  base.Ref,
+	}
+
+	// check if the template is captured from a full url
+	u, err := url.Parse(base.Name)
+	if err == nil && len(u.Scheme) > 0 {
+		ref, err := url.Parse(module)
+		if err != nil {
+			return "", err
+		}
+
+		resolved := u.ResolveReference(ref)
+
+		// ensure the module doesn't leave the origin of the template
+		if !strings.EqualFold(resolved.Scheme, u.Scheme) || !strings.EqualFold(resolved.Host, u.Host) {
+			return "", fmt.Errorf("module %s is outside of origin %s://%s", module, u.Scheme, u.Host)
+		}
+
+		src.Name = resolved.String()
+	} else {
+		name := path.Join(path.Dir(base.Name), module)
+		if strings.HasPrefix(module, "/") {
+			name = path.Clean(module)
+		}
+
+		name = strings.TrimPrefix(name, "/")
+
+		// ensure the module doesn't leave the repository
+		if name == ".." || strings.HasPrefix(name, "../") {
+			return "", fmt.Errorf("module %s is outside of repository %s/%s", module, base.Org, base.Repo)
+		}
+
+		src.Name = name
+	}
+
+	// ensure the module for a pinned template can't change
+	if l.pinned && !commitRegex.MatchString(src.Ref) {
+		return "", fmt.Errorf("module %s must be captured from a commit sha for a template with a pinned sha256", module)
+	}
+
+	id := fmt.Sprintf("%s:%s", l.kind, src.Name)
+	if len(src.Org) > 0 {
+		id = fmt.Sprintf("%s:%s/%s/%s/%s@%s", l.kind, src.Host, src.Org, src.Repo, src.Name, src.Ref)
+	}
+
+	l.sources[id] = src
+
+	return id, nil
+}
+
+// Source captures the module from the registry.
+func (l *registryLoader) Source(ctx context.Context, id string) ([]byte, error) {
+	src, ok := l.sources[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown module %s", id)
+	}
+
+	return registry.TemplateWithContext(ctx, l.svc, l.user, src)
+}
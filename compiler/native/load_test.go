@@ -0,0 +1,149 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"testing"
+
+	"github.com/go-vela/compiler/registry"
+)
+
+func TestNative_registryLoader_Resolve(t *testing.T) {
+	// setup types
+	repo := &registry.Source{
+		Host: "github.com",
+		Org:  "foo",
+		Repo: "bar",
+		Name: "templates/template.star",
+		Ref:  "main",
+	}
+
+	full := &registry.Source{
+		Host: "example.com",
+		Name: "https://example.com/templates/template.star",
+	}
+
+	commit := &registry.Source{
+		Host: "github.com",
+		Org:  "foo",
+		Repo: "bar",
+		Name: "templates/template.star",
+		Ref:  "3c6f2a1b8d8e4f5a6b7c8d9e0f1a2b3c4d5e6f7a",
+	}
+
+	// setup tests
+	tests := []struct {
+		name    string
+		source  *registry.Source
+		pinned  bool
+		module  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "relative",
+			source: repo,
+			module: "lib/helpers.star",
+			want:   "github:github.com/foo/bar/templates/lib/helpers.star@main",
+		},
+		{
+			name:   "parent",
+			source: repo,
+			module: "../helpers.star",
+			want:   "github:github.com/foo/bar/helpers.star@main",
+		},
+		{
+			name:   "root",
+			source: repo,
+			module: "/lib/helpers.star",
+			want:   "github:github.com/foo/bar/lib/helpers.star@main",
+		},
+		{
+			name:    "outside repository",
+			source:  repo,
+			module:  "../../helpers.star",
+			wantErr: true,
+		},
+		{
+			name:   "url",
+			source: full,
+			module: "lib/helpers.star",
+			want:   "github:https://example.com/templates/lib/helpers.star",
+		},
+		{
+			name:   "url same origin",
+			source: full,
+			module: "https://example.com/lib/helpers.star",
+			want:   "github:https://example.com/lib/helpers.star",
+		},
+		{
+			name:    "url other host",
+			source:  full,
+			module:  "https://other.example.com/lib/helpers.star",
+			wantErr: true,
+		},
+		{
+			name:    "url other scheme",
+			source:  full,
+			module:  "http://example.com/lib/helpers.star",
+			wantErr: true,
+		},
+		{
+			name:    "url protocol relative",
+			source:  full,
+			module:  "//169.254.169.254/latest/meta-data",
+			wantErr: true,
+		},
+		{
+			name:   "pinned commit",
+			source: commit,
+			pinned: true,
+			module: "lib/helpers.star",
+			want:   "github:github.com/foo/bar/templates/lib/helpers.star@3c6f2a1b8d8e4f5a6b7c8d9e0f1a2b3c4d5e6f7a",
+		},
+		{
+			name:    "pinned branch",
+			source:  repo,
+			pinned:  true,
+			module:  "lib/helpers.star",
+			wantErr: true,
+		},
+		{
+			name:    "pinned url",
+			source:  full,
+			pinned:  true,
+			module:  "lib/helpers.star",
+			wantErr: true,
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		l := newRegistryLoader("GitHub", nil, nil, test.source)
+		l.pinned = test.pinned
+
+		got, err := l.Resolve("", test.module)
+
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Resolve for %s should have returned err", test.name)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Resolve for %s returned err: %v", test.name, err)
+		}
+
+		if got != test.want {
+			t.Errorf("Resolve for %s is %s, want %s", test.name, got, test.want)
+		}
+
+		if _, ok := l.sources[got]; !ok {
+			t.Errorf("Resolve for %s didn't capture the source for %s", test.name, got)
+		}
+	}
+}
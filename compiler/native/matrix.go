@@ -0,0 +1,452 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/buildkite/yaml"
+
+	"github.com/go-vela/types/raw"
+	types "github.com/go-vela/types/yaml"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// maxMatrixCombinations is the maximum number of
+// combinations produced by the matrix for a step or stage.
+const maxMatrixCombinations = 256
+
+// nameRegex matches the characters that aren't
+// supported in the names for the expanded matrix.
+var nameRegex = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// matrix represents the axes declared by the matrix key
+// of a step or stage that is expanded into a step or stage
+// for each combination of the values for the axes.
+//
+//	matrix:
+//	  GO_VERSION: [ "1.16", "1.17" ]
+//	  GOOS: [ linux, darwin ]
+//	  exclude:
+//	    - GO_VERSION: "1.16"
+//	      GOOS: darwin
+//	  include:
+//	    - GO_VERSION: "1.18"
+//	      GOOS: linux
+type matrix struct {
+	// axes contains the names of the axes in the declared order
+	axes []string
+	// values contains the values for each axis
+	values map[string][]string
+	// exclude contains the partial combinations removed from the product of the axes
+	exclude []map[string]string
+	// include contains the combinations added after the product of the axes
+	include []map[string]string
+}
+
+// combination represents the names and
+// values of the axes for a single entry.
+type combination struct {
+	keys   []string
+	values map[string]string
+}
+
+// MatrixStages expands the matrix for each stage and for each step
+// in every stage in a yaml configuration. The needs declarations
+// referencing an expanded stage are replaced by the expanded stages.
+//
+// nolint: funlen,lll // ignore function length and long line length due to comments
+func (c *client) MatrixStages(s types.StageSlice) (types.StageSlice, error) {
+	stages := types.StageSlice{}
+	// expanded contains the names of the stages expanded from each stage
+	expanded := make(map[string][]string)
+
+	for _, stage := range s {
+		// expand the matrix for the steps in the stage
+		steps, err := c.MatrixSteps(stage.Steps)
+		if err != nil {
+			return nil, err
+		}
+
+		stage.Steps = steps
+
+		m, err := c.matrix(stage, "stage "+stage.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// check if the stage declares a matrix
+		if m == nil {
+			stages = append(stages, stage)
+
+			continue
+		}
+
+		for _, combo := range m.combinations() {
+			cp, err := c.copyStage(stage)
+			if err != nil {
+				return nil, c.positions.wrap(err, stage, "matrix")
+			}
+
+			cp.Name = combo.name(stage.Name)
+
+			// inject the values for the combination into every step for the stage
+			for _, step := range cp.Steps {
+				combo.inject(step)
+			}
+
+			stages = append(stages, cp)
+			expanded[stage.Name] = append(expanded[stage.Name], cp.Name)
+		}
+	}
+
+	// verify the expanded stages have unique names
+	found := make(map[string]bool)
+
+	for _, stage := range stages {
+		if found[stage.Name] {
+			return nil, c.positions.wrap(fmt.Errorf("stage %s is not unique after expanding the matrix", stage.Name), stage, "name")
+		}
+
+		found[stage.Name] = true
+	}
+
+	// replace the needs for the expanded stages
+	for _, stage := range stages {
+		needs := raw.StringSlice{}
+
+		for _, need := range stage.Needs {
+			if names, ok := expanded[need]; ok {
+				needs = append(needs, names...)
+
+				continue
+			}
+
+			needs = append(needs, need)
+		}
+
+		if len(stage.Needs) > 0 {
+			stage.Needs = needs
+		}
+	}
+
+	return stages, nil
+}
+
+// MatrixSteps expands the matrix for each step in a yaml configuration.
+//
+// nolint: lll // ignore long line length due to error messages
+func (c *client) MatrixSteps(s types.StepSlice) (types.StepSlice, error) {
+	steps := types.StepSlice{}
+
+	for _, step := range s {
+		m, err := c.matrix(step, "step "+step.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// check if the step declares a matrix
+		if m == nil {
+			steps = append(steps, step)
+
+			continue
+		}
+
+		for _, combo := range m.combinations() {
+			cp, err := c.copyStep(step)
+			if err != nil {
+				return nil, c.positions.wrap(err, step, "matrix")
+			}
+
+			cp.Name = combo.name(step.Name)
+
+			combo.inject(cp)
+
+			steps = append(steps, cp)
+		}
+	}
+
+	// verify the expanded steps have unique names
+	found := make(map[string]bool)
+
+	for _, step := range steps {
+		if found[step.Name] {
+			return nil, c.positions.wrap(fmt.Errorf("step %s is not unique after expanding the matrix", step.Name), step, "name")
+		}
+
+		found[step.Name] = true
+	}
+
+	return steps, nil
+}
+
+// matrix is a helper function that parses the matrix declared for
+// the parsed step or stage, or returns nil if no matrix is declared.
+//
+// nolint: gocyclo,funlen // ignore cyclomatic complexity and function length
+func (c *client) matrix(v interface{}, kind string) (*matrix, error) {
+	// the keys aren't captured for the copies created while expanding a matrix
+	ext := c.extension(v)
+	if ext == nil || ext.Matrix == nil {
+		return nil, nil
+	}
+
+	n := ext.Matrix
+
+	if n.Kind != yaml3.MappingNode {
+		return nil, c.positions.wrap(fmt.Errorf("matrix for %s must be a map of axes", kind), v, "matrix")
+	}
+
+	m := &matrix{
+		values: make(map[string][]string),
+	}
+
+	// mapping nodes contain the key followed by the value for each field
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key := n.Content[i].Value
+		value := resolve(n.Content[i+1])
+
+		switch key {
+		case "exclude", "include":
+			entries := []map[string]string{}
+
+			err := value.Decode(&entries)
+			if err != nil {
+				return nil, ext.wrapNode(fmt.Errorf("matrix %s for %s must be a list of maps", key, kind), value)
+			}
+
+			if key == "exclude" {
+				m.exclude = entries
+			} else {
+				m.include = entries
+			}
+		default:
+			values := []string{}
+
+			err := value.Decode(&values)
+			if err != nil || len(values) == 0 {
+				return nil, ext.wrapNode(fmt.Errorf("matrix axis %s for %s must be a list of values", key, kind), value)
+			}
+
+			m.axes = append(m.axes, key)
+			m.values[key] = values
+		}
+	}
+
+	if len(m.axes) == 0 && len(m.include) == 0 {
+		return nil, c.positions.wrap(fmt.Errorf("matrix for %s must provide an axis", kind), v, "matrix")
+	}
+
+	if count := m.count(); count > maxMatrixCombinations {
+		// nolint: lll // ignore long line length due to error message
+		return nil, c.positions.wrap(fmt.Errorf("matrix for %s produces %d combinations, exceeding the limit of %d", kind, count, maxMatrixCombinations), v, "matrix")
+	}
+
+	return m, nil
+}
+
+// count returns the maximum number of combinations for the matrix.
+func (m *matrix) count() int {
+	count := 0
+
+	if len(m.axes) > 0 {
+		count = 1
+
+		for _, axis := range m.axes {
+			count *= len(m.values[axis])
+
+			// stop counting once the limit is exceeded to avoid overflows
+			if count > maxMatrixCombinations {
+				return count
+			}
+		}
+	}
+
+	return count + len(m.include)
+}
+
+// combinations returns the combinations for the matrix in a deterministic
+// order: the product of the axes in the declared order, without the
+// excluded combinations, followed by the included combinations.
+func (m *matrix) combinations() []*combination {
+	combos := []*combination{}
+
+	if len(m.axes) > 0 {
+		combos = append(combos, &combination{values: make(map[string]string)})
+	}
+
+	// create the product of the values for each axis
+	for _, axis := range m.axes {
+		product := []*combination{}
+
+		for _, combo := range combos {
+			for _, value := range m.values[axis] {
+				product = append(product, combo.with(axis, value))
+			}
+		}
+
+		combos = product
+	}
+
+	// remove the excluded combinations
+	filtered := []*combination{}
+
+	for _, combo := range combos {
+		excluded := false
+
+		for _, exclude := range m.exclude {
+			if combo.matches(exclude) {
+				excluded = true
+
+				break
+			}
+		}
+
+		if !excluded {
+			filtered = append(filtered, combo)
+		}
+	}
+
+	// add the included combinations
+	for _, include := range m.include {
+		combo := &combination{values: make(map[string]string)}
+
+		// capture the declared axes first followed by the sorted additional keys
+		for _, axis := range m.axes {
+			if value, ok := include[axis]; ok {
+				combo = combo.with(axis, value)
+			}
+		}
+
+		extra := []string{}
+
+		for k := range include {
+			if _, ok := m.values[k]; !ok {
+				extra = append(extra, k)
+			}
+		}
+
+		sort.Strings(extra)
+
+		for _, k := range extra {
+			combo = combo.with(k, include[k])
+		}
+
+		// skip the combinations that already exist
+		duplicate := false
+
+		for _, existing := range filtered {
+			if len(existing.keys) == len(combo.keys) && existing.matches(combo.values) {
+				duplicate = true
+
+				break
+			}
+		}
+
+		if !duplicate && len(combo.keys) > 0 {
+			filtered = append(filtered, combo)
+		}
+	}
+
+	return filtered
+}
+
+// with returns a copy of the combination with the value for the axis.
+func (c *combination) with(axis, value string) *combination {
+	combo := &combination{
+		keys:   append(append([]string{}, c.keys...), axis),
+		values: make(map[string]string),
+	}
+
+	for k, v := range c.values {
+		combo.values[k] = v
+	}
+
+	combo.values[axis] = value
+
+	return combo
+}
+
+// matches returns true when the combination contains
+// every value for the axes in the partial combination.
+func (c *combination) matches(partial map[string]string) bool {
+	for k, v := range partial {
+		if value, ok := c.values[k]; !ok || value != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// name returns the name for the combination, i.e. test_1.17_linux.
+func (c *combination) name(base string) string {
+	parts := []string{base}
+
+	for _, k := range c.keys {
+		parts = append(parts, nameRegex.ReplaceAllString(c.values[k], "-"))
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// inject sets the values for the combination
+// as environment variables for the step.
+func (c *combination) inject(s *types.Step) {
+	if s.Environment == nil {
+		s.Environment = make(raw.StringSliceMap)
+	}
+
+	for _, k := range c.keys {
+		s.Environment[k] = c.values[k]
+	}
+}
+
+// copyStep is a helper function that creates a deep copy of
+// the step that retains the location of the original step.
+func (c *client) copyStep(s *types.Step) (*types.Step, error) {
+	body, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal configuration: %v", err)
+	}
+
+	cp := new(types.Step)
+
+	err = yaml.Unmarshal(body, cp)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal configuration: %v", err)
+	}
+
+	c.positions.alias(cp, s)
+
+	return cp, nil
+}
+
+// copyStage is a helper function that creates a deep copy of
+// the stage that retains the location of the original stage.
+func (c *client) copyStage(s *types.Stage) (*types.Stage, error) {
+	cp := &types.Stage{
+		Name:  s.Name,
+		Needs: append(raw.StringSlice{}, s.Needs...),
+		Steps: types.StepSlice{},
+	}
+
+	for _, step := range s.Steps {
+		stepCopy, err := c.copyStep(step)
+		if err != nil {
+			return nil, err
+		}
+
+		cp.Steps = append(cp.Steps, stepCopy)
+	}
+
+	c.positions.alias(cp, s)
+
+	return cp, nil
+}
@@ -0,0 +1,403 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/go-vela/types/library"
+	"github.com/go-vela/types/raw"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/urfave/cli/v2"
+)
+
+func TestNative_MatrixSteps(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	config := `version: "1"
+steps:
+  - name: lint
+    image: golangci/golangci-lint
+    commands: [golangci-lint run]
+
+  - name: test
+    image: golang:${GO_VERSION}
+    environment:
+      CGO_ENABLED: "0"
+    parameters:
+      version: ${GO_VERSION}
+    matrix:
+      GO_VERSION: [ "1.16", "1.17" ]
+      GOOS: [ linux, darwin ]
+      exclude:
+        - GO_VERSION: "1.16"
+          GOOS: darwin
+      include:
+        - GO_VERSION: "1.18"
+          GOOS: linux
+          GOARCH: arm64
+        - GO_VERSION: "1.17"
+          GOOS: linux
+    commands: [go test ./...]
+`
+
+	wantNames := []string{"lint", "test_1.16_linux", "test_1.17_linux", "test_1.17_darwin", "test_1.18_linux_arm64"}
+
+	wantEnv := []raw.StringSliceMap{
+		nil,
+		{"CGO_ENABLED": "0", "GO_VERSION": "1.16", "GOOS": "linux"},
+		{"CGO_ENABLED": "0", "GO_VERSION": "1.17", "GOOS": "linux"},
+		{"CGO_ENABLED": "0", "GO_VERSION": "1.17", "GOOS": "darwin"},
+		{"CGO_ENABLED": "0", "GO_VERSION": "1.18", "GOOS": "linux", "GOARCH": "arm64"},
+	}
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	p, err := compiler.Parse([]byte(config))
+	if err != nil {
+		t.Errorf("Parse returned err: %v", err)
+	}
+
+	// run test
+	got, err := compiler.MatrixSteps(p.Steps)
+	if err != nil {
+		t.Errorf("MatrixSteps returned err: %v", err)
+	}
+
+	names := []string{}
+	env := []raw.StringSliceMap{}
+
+	for _, step := range got {
+		names = append(names, step.Name)
+		env = append(env, step.Environment)
+	}
+
+	if diff := cmp.Diff(wantNames, names); diff != "" {
+		t.Errorf("MatrixSteps names mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(wantEnv, env); diff != "" {
+		t.Errorf("MatrixSteps environment mismatch (-want +got):\n%s", diff)
+	}
+
+	// the copies don't share the parameters of the original step
+	got[1].Parameters["version"] = "1.16"
+
+	if got[2].Parameters["version"] != "${GO_VERSION}" {
+		t.Errorf("MatrixSteps parameters are %v, want %v", got[2].Parameters, "${GO_VERSION}")
+	}
+
+	// the expanded steps are located at the matrix step
+	pos := compiler.positions.position(got[3], "matrix")
+	if pos == nil || pos.Line != 13 {
+		t.Errorf("MatrixSteps position is %v, want line 13", pos)
+	}
+
+	// expanding the matrix again doesn't expand the copies
+	again, err := compiler.MatrixSteps(got)
+	if err != nil {
+		t.Errorf("MatrixSteps returned err: %v", err)
+	}
+
+	if len(again) != len(got) {
+		t.Errorf("MatrixSteps is %d steps, want %d", len(again), len(got))
+	}
+}
+
+func TestNative_MatrixStages(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	config := `version: "1"
+stages:
+  test:
+    matrix:
+      DB: [ postgres, mysql ]
+    steps:
+      - name: unit
+        image: golang:1.17
+        matrix:
+          GOARCH: [ amd64, arm64 ]
+        commands: [go test ./...]
+
+  publish:
+    needs: [ test ]
+    steps:
+      - name: publish
+        image: alpine
+        commands: [echo publish]
+`
+
+	want := map[string][]string{
+		"test_postgres": {"unit_amd64", "unit_arm64"},
+		"test_mysql":    {"unit_amd64", "unit_arm64"},
+		"publish":       {"publish"},
+	}
+
+	wantOrder := []string{"test_postgres", "test_mysql", "publish"}
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	p, err := compiler.Parse([]byte(config))
+	if err != nil {
+		t.Errorf("Parse returned err: %v", err)
+	}
+
+	// run test
+	got, err := compiler.MatrixStages(p.Stages)
+	if err != nil {
+		t.Errorf("MatrixStages returned err: %v", err)
+	}
+
+	stages := make(map[string][]string)
+	order := []string{}
+
+	for _, stage := range got {
+		order = append(order, stage.Name)
+
+		for _, step := range stage.Steps {
+			stages[stage.Name] = append(stages[stage.Name], step.Name)
+		}
+	}
+
+	// stages are parsed from a map so only the expanded stages are ordered
+	if diff := cmp.Diff(want, stages); diff != "" {
+		t.Errorf("MatrixStages mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(order) != 3 || (order[0] != wantOrder[0] && order[1] != wantOrder[0]) {
+		t.Errorf("MatrixStages order is %v, want %v", order, wantOrder)
+	}
+
+	for _, stage := range got {
+		switch stage.Name {
+		case "publish":
+			if diff := cmp.Diff(raw.StringSlice{"test_postgres", "test_mysql", "clone"}, stage.Needs); diff != "" {
+				t.Errorf("MatrixStages needs mismatch (-want +got):\n%s", diff)
+			}
+		case "test_mysql":
+			want := raw.StringSliceMap{"DB": "mysql", "GOARCH": "arm64"}
+
+			if diff := cmp.Diff(want, stage.Steps[1].Environment); diff != "" {
+				t.Errorf("MatrixStages environment mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+}
+
+func TestNative_MatrixSteps_PipelineTypes(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	// setup tests
+	tests := []struct {
+		name         string
+		pipelineType string
+		config       string
+		want         []string
+	}{
+		{
+			name:         "starlark",
+			pipelineType: "starlark",
+			config: `
+def main(ctx):
+    return {
+        "version": "1",
+        "steps": [
+            {"name": "test", "image": "golang", "matrix": {"GOOS": ["linux", "darwin"]}, "commands": ["go test ./..."]},
+        ],
+    }
+`,
+			want: []string{"test_linux", "test_darwin"},
+		},
+		{
+			name:         "template",
+			pipelineType: "yaml",
+			config: `version: "1"
+templates:
+  - name: matrix
+    source: testdata/matrix/template.yml
+    type: github
+steps:
+  - name: sample
+    template:
+      name: matrix
+      vars:
+        image: golang
+`,
+			want: []string{"sample_test_linux", "sample_test_darwin"},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating compiler returned err: %v", err)
+		}
+
+		compiler.WithLocal(true)
+		compiler.WithRepo(&library.Repo{PipelineType: &test.pipelineType})
+
+		p, err := compiler.Parse([]byte(test.config))
+		if err != nil {
+			t.Errorf("Parse for %s returned err: %v", test.name, err)
+
+			continue
+		}
+
+		steps, _, _, _, err := compiler.ExpandSteps(p, mapFromTemplates(p.Templates))
+		if err != nil {
+			t.Errorf("ExpandSteps for %s returned err: %v", test.name, err)
+		}
+
+		got, err := compiler.MatrixSteps(steps)
+		if err != nil {
+			t.Errorf("MatrixSteps for %s returned err: %v", test.name, err)
+		}
+
+		names := []string{}
+		for _, step := range got {
+			names = append(names, step.Name)
+		}
+
+		if diff := cmp.Diff(test.want, names); diff != "" {
+			t.Errorf("MatrixSteps for %s mismatch (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestNative_MatrixSteps_Invalid(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	// setup tests
+	tests := []struct {
+		name    string
+		matrix  string
+		wantErr string
+	}{
+		{
+			name:    "not a map",
+			matrix:  "[ linux, darwin ]",
+			wantErr: "line 5, column 5: matrix for step test must be a map of axes",
+		},
+		{
+			name:    "not a list",
+			matrix:  "\n      GOOS: linux",
+			wantErr: "line 6, column 13: matrix axis GOOS for step test must be a list of values",
+		},
+		{
+			name:    "empty axis",
+			matrix:  "\n      GOOS: []",
+			wantErr: "matrix axis GOOS for step test must be a list of values",
+		},
+		{
+			name:    "invalid exclude",
+			matrix:  "\n      GOOS: [ linux ]\n      exclude: linux",
+			wantErr: "matrix exclude for step test must be a list of maps",
+		},
+		{
+			name:    "no axes",
+			matrix:  "{}",
+			wantErr: "matrix for step test must provide an axis",
+		},
+		{
+			name:    "too many combinations",
+			matrix:  "\n      A: [ 1, 2, 3, 4, 5, 6, 7, 8 ]\n      B: [ 1, 2, 3, 4, 5, 6, 7, 8 ]\n      C: [ 1, 2, 3, 4, 5 ]",
+			wantErr: "matrix for step test produces 320 combinations, exceeding the limit of 256",
+		},
+		{
+			name:    "not unique",
+			matrix:  "\n      GOOS: [ linux ]\n  - name: test_linux\n    image: alpine\n    commands: [ls]",
+			wantErr: "step test_linux is not unique after expanding the matrix",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		config := `version: "1"
+steps:
+  - name: test
+    image: alpine
+    matrix: ` + test.matrix + `
+`
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating compiler returned err: %v", err)
+		}
+
+		p, err := compiler.Parse([]byte(config))
+		if err != nil {
+			t.Errorf("Parse for %s returned err: %v", test.name, err)
+		}
+
+		_, err = compiler.MatrixSteps(p.Steps)
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("MatrixSteps for %s returned err %v, want %s", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestNative_Compile_Matrix(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	config := `version: "1"
+metadata:
+  clone: false
+steps:
+  - name: test
+    image: golang:${GO_VERSION}
+    matrix:
+      GO_VERSION: [ "1.16", "1.17" ]
+    commands: [go test ./...]
+`
+
+	want := map[string]string{
+		"step_localOrg_localRepo_1_init":      "#init",
+		"step_localOrg_localRepo_1_test_1.16": "golang:1.16",
+		"step_localOrg_localRepo_1_test_1.17": "golang:1.17",
+	}
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.WithLocal(true)
+
+	// run test
+	got, err := compiler.Compile([]byte(config))
+	if err != nil {
+		t.Errorf("Compile returned err: %v", err)
+	}
+
+	images := make(map[string]string)
+
+	for _, step := range got.Steps {
+		images[step.ID] = step.Image
+	}
+
+	if diff := cmp.Diff(want, images); diff != "" {
+		t.Errorf("Compile mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"github.com/go-vela/compiler/compiler"
+	"github.com/go-vela/compiler/template"
+
+	"github.com/go-vela/types/yaml"
+
+	"github.com/sirupsen/logrus"
+)
+
+// checkTemplate is a helper function that verifies the compiler version
+// satisfies the minimum compiler version declared by the metadata for the
+// template and captures a warning when the template is deprecated.
+func (c *client) checkTemplate(tmpl *yaml.Template, metadata *template.Metadata) error {
+	err := metadata.Compatible(tmpl.Name, c.Version)
+	if err != nil {
+		return err
+	}
+
+	if warning := metadata.Deprecation(tmpl.Name); len(warning) > 0 {
+		logrus.Tracef("capturing warning for template %s: %s", tmpl.Name, warning)
+
+		c.warn(&compiler.Warning{
+			Code:    compiler.WarningTemplateDeprecated,
+			Message: warning,
+		}, tmpl, "name")
+	}
+
+	return nil
+}
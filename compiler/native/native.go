@@ -5,12 +5,17 @@
 package native
 
 import (
+	"fmt"
+	"path"
 	"time"
 
 	"github.com/go-vela/compiler/compiler"
 
 	"github.com/go-vela/compiler/registry"
 	"github.com/go-vela/compiler/registry/github"
+	"github.com/go-vela/compiler/registry/gitlab"
+	"github.com/go-vela/compiler/registry/url"
+	"github.com/go-vela/compiler/template/starlark"
 
 	"github.com/go-vela/types"
 	"github.com/go-vela/types/library"
@@ -19,6 +24,10 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
+// defaultTemplateDepth is the default maximum
+// depth for expanding nested templates.
+const defaultTemplateDepth = 3
+
 type ModificationConfig struct {
 	Timeout  time.Duration
 	Retries  int
@@ -26,11 +35,81 @@ type ModificationConfig struct {
 	Secret   string
 }
 
+// TemplateCacheConfig represents the settings for
+// caching the templates captured from the registries.
+type TemplateCacheConfig struct {
+	TTL       time.Duration
+	Size      int
+	Directory string
+}
+
+// StarlarkConfig represents the limits for
+// executing Starlark templates and pipelines.
+type StarlarkConfig struct {
+	ExecLimit   uint64
+	Timeout     time.Duration
+	OutputLimit int
+}
+
+// constants for the modes for substituting the
+// environment variables referenced by the steps.
+const (
+	// SubstituteLenient leaves the references to unknown
+	// environment variables unchanged in the steps and
+	// is used when no mode is provided.
+	SubstituteLenient = "lenient"
+	// SubstituteWarn leaves the references to unknown environment
+	// variables unchanged and captures a warning for each variable.
+	SubstituteWarn = "warn"
+	// SubstituteStrict fails the compile when a step
+	// references an unknown environment variable.
+	SubstituteStrict = "strict"
+)
+
+// PlatformConfig represents the details of the Vela
+// platform injected into the environment of the pipeline.
+type PlatformConfig struct {
+	Runtime      string
+	Distribution string
+	Version      string
+}
+
+// constants for the modes for passing the host
+// environment into the containers for a local pipeline.
+const (
+	// LocalEnvironmentAll passes every variable from the host
+	// environment that matches the allowlist and denylist.
+	LocalEnvironmentAll = "all"
+	// LocalEnvironmentNone passes no variables from the host environment.
+	LocalEnvironmentNone = "none"
+)
+
+// LocalEnvironmentConfig represents the policy for passing the host
+// environment into the containers for a local pipeline. The Allow and
+// Deny lists contain glob patterns matched against the variable names.
+// When Allow is empty every variable is allowed, and a variable that
+// matches Deny is never passed into the containers.
+type LocalEnvironmentConfig struct {
+	Mode  string
+	Allow []string
+	Deny  []string
+}
+
 type client struct {
 	Github              registry.Service
 	PrivateGithub       registry.Service
 	UsePrivateGithub    bool
+	Registries          map[string]registry.Service
 	ModificationService ModificationConfig
+	TemplateCache       TemplateCacheConfig
+	TemplateDepth       int
+	TemplateStrict      bool
+	WarnDroppedSteps    bool
+	SubstituteMode      string
+	Starlark            StarlarkConfig
+	LocalEnvironment    LocalEnvironmentConfig
+	Platform            PlatformConfig
+	Version             string
 
 	build    *library.Build
 	comment  string
@@ -39,6 +118,22 @@ type client struct {
 	metadata *types.Metadata
 	repo     *library.Repo
 	user     *library.User
+	vars     map[string]interface{}
+
+	// positions captured while parsing the yaml configuration
+	positions *positions
+	// extensions captured for the keys that aren't supported by the pipeline types
+	extensions map[interface{}]*extension
+	// origins captured while expanding the templates
+	origins map[interface{}]*origin
+	// loaded contains the modules loaded by starlark templates
+	loaded *starlark.Modules
+	// warnings captured while compiling the yaml configuration
+	warnings []*compiler.Warning
+	// traceEnv enables capturing where each environment variable was declared
+	traceEnv bool
+	// traces captured while injecting the environment for the compiled steps
+	traces []*compiler.EnvironmentTrace
 }
 
 // New returns a Pipeline implementation that integrates with the supported registries.
@@ -57,6 +152,71 @@ func New(ctx *cli.Context) (*client, error) {
 		}
 	}
 
+	// set the maximum depth for expanding nested templates
+	c.TemplateDepth = ctx.Int("max-template-depth")
+	if c.TemplateDepth <= 0 {
+		c.TemplateDepth = defaultTemplateDepth
+	}
+
+	// set the strict mode for the template variables
+	c.TemplateStrict = ctx.Bool("template-strict")
+
+	// set whether to capture warnings for the steps
+	// dropped because the ruleset doesn't match the build
+	c.WarnDroppedSteps = ctx.Bool("warn-dropped-steps")
+
+	// set the mode for substituting the environment variables
+	c.SubstituteMode = ctx.String("substitute-mode")
+
+	switch c.SubstituteMode {
+	case "", SubstituteLenient, SubstituteWarn, SubstituteStrict:
+	default:
+		return nil, fmt.Errorf("invalid substitute mode %s", c.SubstituteMode)
+	}
+
+	if ctx.Duration("template-cache-ttl") > 0 {
+		c.TemplateCache = TemplateCacheConfig{
+			TTL:       ctx.Duration("template-cache-ttl"),
+			Size:      ctx.Int("template-cache-size"),
+			Directory: ctx.String("template-cache-dir"),
+		}
+	}
+
+	// set the version of the compiler for checking the
+	// minimum compiler version declared by templates
+	c.Version = ctx.String("compiler-version")
+
+	// set the details of the platform for the environment of the pipeline
+	c.Platform = PlatformConfig{
+		Runtime:      ctx.String("platform-runtime"),
+		Distribution: ctx.String("platform-distribution"),
+		Version:      ctx.String("platform-version"),
+	}
+
+	// default to the version of the compiler
+	if len(c.Platform.Version) == 0 {
+		c.Platform.Version = c.Version
+	}
+
+	// set the limits for executing starlark templates
+	c.Starlark = StarlarkConfig{
+		ExecLimit:   ctx.Uint64("starlark-exec-limit"),
+		Timeout:     ctx.Duration("starlark-timeout"),
+		OutputLimit: ctx.Int("starlark-output-limit"),
+	}
+
+	// set the policy for passing the host environment into a local pipeline
+	c.LocalEnvironment = LocalEnvironmentConfig{
+		Mode:  ctx.String("local-env-mode"),
+		Allow: ctx.StringSlice("local-env-allow"),
+		Deny:  ctx.StringSlice("local-env-deny"),
+	}
+
+	err := validateLocalEnvironment(c.LocalEnvironment)
+	if err != nil {
+		return nil, err
+	}
+
 	// setup github template service
 	github, err := setupGithub()
 	if err != nil {
@@ -77,9 +237,45 @@ func New(ctx *cli.Context) (*client, error) {
 		c.UsePrivateGithub = true
 	}
 
+	// setup gitlab template service
+	gitlab, err := setupGitlab(ctx.String("gitlab-url"), ctx.String("gitlab-token"))
+	if err != nil {
+		return nil, err
+	}
+
+	// setup url template service
+	url, err := setupURL(ctx.String("url-token"), ctx.StringSlice("url-hosts"))
+	if err != nil {
+		return nil, err
+	}
+
+	// setup the template services for each template type
+	c.WithRegistry("github", c.githubRegistry())
+	c.WithRegistry("gitlab", gitlab)
+	c.WithRegistry("url", url)
+
 	return c, nil
 }
 
+// validateLocalEnvironment is a helper function to verify
+// the policy for passing the host environment is valid.
+func validateLocalEnvironment(cfg LocalEnvironmentConfig) error {
+	switch cfg.Mode {
+	case "", LocalEnvironmentAll, LocalEnvironmentNone:
+	default:
+		return fmt.Errorf("invalid local environment mode %s", cfg.Mode)
+	}
+
+	for _, pattern := range append(append([]string{}, cfg.Allow...), cfg.Deny...) {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return fmt.Errorf("invalid local environment pattern %s: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
 // setupGithub is a helper function to setup the
 // Github registry service from the CLI arguments.
 func setupGithub() (registry.Service, error) {
@@ -94,6 +290,22 @@ func setupPrivateGithub(addr, token string) (registry.Service, error) {
 	return github.New(addr, token)
 }
 
+// setupGitlab is a helper function to setup the
+// Gitlab registry service from the CLI arguments.
+func setupGitlab(addr, token string) (registry.Service, error) {
+	logrus.Tracef("Creating %s registry client from CLI configuration", "gitlab")
+	return gitlab.New(addr, token)
+}
+
+// setupURL is a helper function to setup the
+// URL registry service from the CLI arguments.
+//
+// The token is only sent to the hosts provided.
+func setupURL(token string, hosts []string) (registry.Service, error) {
+	logrus.Tracef("Creating %s registry client from CLI configuration", "url")
+	return url.New(token, hosts)
+}
+
 // Duplicate creates a clone of the Engine.
 func (c *client) Duplicate() compiler.Engine {
 	cc := new(client)
@@ -103,6 +315,22 @@ func (c *client) Duplicate() compiler.Engine {
 	cc.PrivateGithub = c.PrivateGithub
 	cc.UsePrivateGithub = c.UsePrivateGithub
 	cc.ModificationService = c.ModificationService
+	cc.TemplateCache = c.TemplateCache
+	cc.TemplateDepth = c.TemplateDepth
+	cc.TemplateStrict = c.TemplateStrict
+	cc.WarnDroppedSteps = c.WarnDroppedSteps
+	cc.SubstituteMode = c.SubstituteMode
+	cc.Starlark = c.Starlark
+	cc.LocalEnvironment = c.LocalEnvironment
+	cc.Platform = c.Platform
+	cc.Version = c.Version
+
+	// copy the template services so registering a
+	// service doesn't modify the existing client
+	cc.Registries = make(map[string]registry.Service)
+	for name, svc := range c.Registries {
+		cc.Registries[name] = svc
+	}
 
 	return cc
 }
@@ -156,6 +384,9 @@ func (c *client) WithPrivateGitHub(url, token string) compiler.Engine {
 		privGithub, _ := setupPrivateGithub(url, token)
 
 		c.PrivateGithub = privGithub
+
+		// refresh the template service for github templates
+		c.WithRegistry("github", c.githubRegistry())
 	}
 
 	return c
@@ -178,3 +409,12 @@ func (c *client) WithUser(u *library.User) compiler.Engine {
 
 	return c
 }
+
+// WithVars sets the pipeline variables in the Engine.
+func (c *client) WithVars(v map[string]interface{}) compiler.Engine {
+	if v != nil {
+		c.vars = v
+	}
+
+	return c
+}
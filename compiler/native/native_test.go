@@ -8,8 +8,12 @@ import (
 	"flag"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/go-vela/compiler/registry"
 	"github.com/go-vela/compiler/registry/github"
+	"github.com/go-vela/compiler/registry/gitlab"
+	registryURL "github.com/go-vela/compiler/registry/url"
 
 	"github.com/go-vela/types"
 	"github.com/go-vela/types/library"
@@ -22,8 +26,16 @@ func TestNative_New(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
 	c := cli.NewContext(nil, set, nil)
 	public, _ := github.New("", "")
+	gl, _ := gitlab.New("", "")
+	u, _ := registryURL.New("", nil)
 	want := &client{
-		Github: public,
+		Github:        public,
+		TemplateDepth: defaultTemplateDepth,
+	}
+	want.Registries = map[string]registry.Service{
+		"github": want.githubRegistry(),
+		"gitlab": gl,
+		"url":    u,
 	}
 
 	// run test
@@ -46,13 +58,56 @@ func TestNative_New_PrivateGithub(t *testing.T) {
 	set.Bool("github-driver", true, "doc")
 	set.String("github-url", url, "doc")
 	set.String("github-token", token, "doc")
+	set.Bool("warn-dropped-steps", true, "doc")
 	c := cli.NewContext(nil, set, nil)
 	public, _ := github.New("", "")
 	private, _ := github.New(url, token)
+	gl, _ := gitlab.New("", "")
+	u, _ := registryURL.New("", nil)
 	want := &client{
 		Github:           public,
 		PrivateGithub:    private,
 		UsePrivateGithub: true,
+		TemplateDepth:    defaultTemplateDepth,
+		WarnDroppedSteps: true,
+	}
+	want.Registries = map[string]registry.Service{
+		"github": want.githubRegistry(),
+		"gitlab": gl,
+		"url":    u,
+	}
+
+	// run test
+	got, err := New(c)
+
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("New is %v, want %v", got, want)
+	}
+}
+
+func TestNative_New_Gitlab(t *testing.T) {
+	// setup types
+	url := "http://gitlab.example.com"
+	token := "someToken"
+	set := flag.NewFlagSet("test", 0)
+	set.String("gitlab-url", url, "doc")
+	set.String("gitlab-token", token, "doc")
+	c := cli.NewContext(nil, set, nil)
+	public, _ := github.New("", "")
+	gl, _ := gitlab.New(url, token)
+	u, _ := registryURL.New("", nil)
+	want := &client{
+		Github:        public,
+		TemplateDepth: defaultTemplateDepth,
+	}
+	want.Registries = map[string]registry.Service{
+		"github": want.githubRegistry(),
+		"gitlab": gl,
+		"url":    u,
 	}
 
 	// run test
@@ -67,6 +122,189 @@ func TestNative_New_PrivateGithub(t *testing.T) {
 	}
 }
 
+func TestNative_New_Starlark(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.Uint64("starlark-exec-limit", 10000, "doc")
+	set.Duration("starlark-timeout", 5*time.Second, "doc")
+	set.Int("starlark-output-limit", 1024, "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	want := StarlarkConfig{
+		ExecLimit:   10000,
+		Timeout:     5 * time.Second,
+		OutputLimit: 1024,
+	}
+
+	// run test
+	got, err := New(c)
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	if !reflect.DeepEqual(got.Starlark, want) {
+		t.Errorf("New Starlark is %v, want %v", got.Starlark, want)
+	}
+
+	if !reflect.DeepEqual(got.Duplicate().(*client).Starlark, want) {
+		t.Errorf("Duplicate Starlark is %v, want %v", got.Duplicate().(*client).Starlark, want)
+	}
+}
+
+func TestNative_New_Version(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.String("compiler-version", "v0.10.0", "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	// run test
+	got, err := New(c)
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	if got.Version != "v0.10.0" {
+		t.Errorf("New Version is %s, want %s", got.Version, "v0.10.0")
+	}
+
+	if got.Duplicate().(*client).Version != "v0.10.0" {
+		t.Errorf("Duplicate Version is %s, want %s", got.Duplicate().(*client).Version, "v0.10.0")
+	}
+}
+
+func TestNative_New_Platform(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		version string
+		want    PlatformConfig
+	}{
+		{
+			version: "v0.11.0",
+			want:    PlatformConfig{Runtime: "docker", Distribution: "linux", Version: "v0.11.0"},
+		},
+		{
+			// the version of the compiler is used by default
+			version: "",
+			want:    PlatformConfig{Runtime: "docker", Distribution: "linux", Version: "v0.10.0"},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		set := flag.NewFlagSet("test", 0)
+		set.String("compiler-version", "v0.10.0", "doc")
+		set.String("platform-runtime", "docker", "doc")
+		set.String("platform-distribution", "linux", "doc")
+		set.String("platform-version", test.version, "doc")
+		c := cli.NewContext(nil, set, nil)
+
+		got, err := New(c)
+		if err != nil {
+			t.Errorf("New returned err: %v", err)
+		}
+
+		if !reflect.DeepEqual(got.Platform, test.want) {
+			t.Errorf("New Platform is %v, want %v", got.Platform, test.want)
+		}
+
+		if !reflect.DeepEqual(got.Duplicate().(*client).Platform, test.want) {
+			t.Errorf("Duplicate Platform is %v, want %v", got.Duplicate().(*client).Platform, test.want)
+		}
+	}
+}
+
+func TestNative_New_LocalEnvironment(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.String("local-env-mode", "all", "doc")
+	set.Var(cli.NewStringSlice("GO*", "HOME"), "local-env-allow", "doc")
+	set.Var(cli.NewStringSlice("*_TOKEN"), "local-env-deny", "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	want := LocalEnvironmentConfig{
+		Mode:  "all",
+		Allow: []string{"GO*", "HOME"},
+		Deny:  []string{"*_TOKEN"},
+	}
+
+	// run test
+	got, err := New(c)
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	if !reflect.DeepEqual(got.LocalEnvironment, want) {
+		t.Errorf("New LocalEnvironment is %v, want %v", got.LocalEnvironment, want)
+	}
+
+	if !reflect.DeepEqual(got.Duplicate().(*client).LocalEnvironment, want) {
+		t.Errorf("Duplicate LocalEnvironment is %v, want %v", got.Duplicate().(*client).LocalEnvironment, want)
+	}
+}
+
+func TestNative_New_SubstituteMode(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		mode    string
+		wantErr bool
+	}{
+		{mode: "", wantErr: false},
+		{mode: "lenient", wantErr: false},
+		{mode: "warn", wantErr: false},
+		{mode: "strict", wantErr: false},
+		{mode: "loose", wantErr: true},
+	}
+
+	// run tests
+	for _, test := range tests {
+		set := flag.NewFlagSet("test", 0)
+		set.String("substitute-mode", test.mode, "doc")
+		c := cli.NewContext(nil, set, nil)
+
+		got, err := New(c)
+
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("New for %s should have returned err", test.mode)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("New for %s returned err: %v", test.mode, err)
+		}
+
+		if got.Duplicate().(*client).SubstituteMode != test.mode {
+			t.Errorf("Duplicate SubstituteMode is %s, want %s", got.Duplicate().(*client).SubstituteMode, test.mode)
+		}
+	}
+}
+
+func TestNative_New_LocalEnvironment_Invalid(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		mode  string
+		allow string
+	}{
+		{mode: "some", allow: "HOME"},
+		{mode: "all", allow: "GO["},
+	}
+
+	// run tests
+	for _, test := range tests {
+		set := flag.NewFlagSet("test", 0)
+		set.String("local-env-mode", test.mode, "doc")
+		set.Var(cli.NewStringSlice(test.allow), "local-env-allow", "doc")
+		c := cli.NewContext(nil, set, nil)
+
+		_, err := New(c)
+		if err == nil {
+			t.Errorf("New for mode %s and allow %s should have returned err", test.mode, test.allow)
+		}
+	}
+}
+
 func TestNative_DuplicateRetainSettings(t *testing.T) {
 	// setup types
 	url := "http://foo.example.com"
@@ -78,10 +316,18 @@ func TestNative_DuplicateRetainSettings(t *testing.T) {
 	c := cli.NewContext(nil, set, nil)
 	public, _ := github.New("", "")
 	private, _ := github.New(url, token)
+	gl, _ := gitlab.New("", "")
+	u, _ := registryURL.New("", nil)
 	want := &client{
 		Github:           public,
 		PrivateGithub:    private,
 		UsePrivateGithub: true,
+		TemplateDepth:    defaultTemplateDepth,
+	}
+	want.Registries = map[string]registry.Service{
+		"github": want.githubRegistry(),
+		"gitlab": gl,
+		"url":    u,
 	}
 
 	// run test
@@ -310,3 +556,24 @@ func TestNative_WithUser(t *testing.T) {
 		t.Errorf("WithUser is %v, want %v", got, want)
 	}
 }
+
+func TestNative_WithVars(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	vars := map[string]interface{}{"image": "alpine"}
+
+	want, _ := New(c)
+	want.vars = vars
+
+	// run test
+	got, err := New(c)
+	if err != nil {
+		t.Errorf("Unable to create new compiler: %v", err)
+	}
+
+	if !reflect.DeepEqual(got.WithVars(vars), want) {
+		t.Errorf("WithVars is %v, want %v", got, want)
+	}
+}
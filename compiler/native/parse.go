@@ -5,6 +5,7 @@
 package native
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -44,7 +45,25 @@ func (c *client) ParseRaw(v interface{}) (string, error) {
 
 // Parse converts an object to a yaml configuration.
 func (c *client) Parse(v interface{}) (*types.Build, error) {
-	var p *types.Build
+	return c.ParseWithContext(context.Background(), v)
+}
+
+// ParseWithContext converts an object to a yaml configuration and
+// stops rendering templated pipelines when the context is done.
+func (c *client) ParseWithContext(ctx context.Context, v interface{}) (*types.Build, error) {
+	var (
+		p *types.Build
+		// b contains the yaml configuration for the pipeline
+		b []byte
+	)
+
+	// reset the locations, extensions, origins, modules, warnings and traces captured from a previous yaml configuration
+	c.positions = nil
+	c.extensions = nil
+	c.origins = nil
+	c.loaded = nil
+	c.warnings = nil
+	c.traces = nil
 
 	switch c.repo.GetPipelineType() {
 	case constants.PipelineTypeGo:
@@ -53,49 +72,109 @@ func (c *client) Parse(v interface{}) (*types.Build, error) {
 		if err != nil {
 			return nil, err
 		}
-		p, err = native.RenderBuild(parsedRaw, c.EnvironmentBuild())
+		// capture the variables for the pipeline
+		vars, err := c.pipelineVars(fileName(v))
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := native.RenderBuildWithVars(ctx, parsedRaw, c.EnvironmentBuild(), vars)
 		if err != nil {
 			return nil, err
 		}
+
+		p, b = result.Build, result.Raw
 	case constants.PipelineTypeStarlark:
 		// expand the base configuration
 		parsedRaw, err := c.ParseRaw(v)
 		if err != nil {
 			return nil, err
 		}
-		p, err = starlark.RenderBuild(parsedRaw, c.EnvironmentBuild())
+		// capture the variables for the pipeline
+		vars, err := c.pipelineVars(fileName(v))
+		if err != nil {
+			return nil, err
+		}
+
+		// modules are only loaded from the local filesystem for the base configuration
+		var loader starlark.Loader
+		if c.local {
+			loader = &localLoader{template: fileName(v)}
+		}
+
+		result, err := starlark.RenderBuildWithOptions(ctx, parsedRaw, c.EnvironmentBuild(), vars, c.starlarkOptions(loader))
 		if err != nil {
 			return nil, err
 		}
+
+		p, b = result.Build, result.Raw
 	case constants.PipelineTypeYAML, "":
-		switch v := v.(type) {
-		case []byte:
-			return ParseBytes(v)
-		case *os.File:
-			return ParseFile(v)
-		case io.Reader:
-			return ParseReader(v)
-		case string:
-			// check if string is path to file
-			_, err := os.Stat(v)
-			if err == nil {
-				// parse string as path to yaml configuration
-				return ParsePath(v)
-			}
-
-			// parse string as yaml configuration
-			return ParseString(v)
-		default:
-			return nil, fmt.Errorf("unable to parse yaml: unrecognized type %T", v)
+		// capture the raw yaml configuration
+		parsedRaw, err := c.ParseRaw(v)
+		if err != nil {
+			return nil, err
 		}
+
+		// capture the name of the file for the yaml configuration
+		file := fileName(v)
+
+		p, err = ParseString(parsedRaw)
+		if err != nil {
+			return nil, newPositions(file, []byte(parsedRaw), nil).wrapLine(err)
+		}
+
+		b = []byte(parsedRaw)
+
+		// capture the locations of the nodes in the yaml configuration
+		c.positions = newPositions(file, b, p)
 	default:
 		// nolint:lll // detailed error message
 		return nil, fmt.Errorf("unable to parse config: unrecognized pipeline_type of %s", c.repo.GetPipelineType())
 	}
 
+	// capture the keys that aren't supported by the pipeline types
+	root, err := c.extend(b, p, c.positions)
+	if err != nil {
+		return nil, err
+	}
+
+	// check if the pipeline includes other files
+	n := mappingValue(root, "includes")
+	if n == nil {
+		return p, nil
+	}
+
+	// includes are only supported for yaml pipelines
+	if c.positions == nil {
+		return nil, fmt.Errorf("includes are not supported for %s pipelines", c.repo.GetPipelineType())
+	}
+
+	// merge the partial yaml configurations declared by the includes
+	err = c.includeConfigs(ctx, p, n)
+	if err != nil {
+		return nil, err
+	}
+
 	return p, nil
 }
 
+// fileName is a helper function that returns the name of
+// the file for the object, or empty if it isn't a file.
+func fileName(v interface{}) string {
+	switch v := v.(type) {
+	case *os.File:
+		return v.Name()
+	case string:
+		// check if string is path to file
+		_, err := os.Stat(v)
+		if err == nil {
+			return v
+		}
+	}
+
+	return ""
+}
+
 // ParseBytes converts a byte slice to a yaml configuration.
 func ParseBytes(b []byte) (*types.Build, error) {
 	config := new(types.Build)
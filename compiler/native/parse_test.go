@@ -899,6 +899,54 @@ func Test_client_Parse(t *testing.T) {
 	}
 }
 
+func Test_client_Parse_Vars(t *testing.T) {
+	// setup types
+	want := &yaml.Build{
+		Version: "1",
+		Steps: yaml.StepSlice{
+			{
+				Name:  "foo",
+				Image: "alpine",
+				Pull:  "not_present",
+				Parameters: map[string]interface{}{
+					"registry": "foo",
+				},
+			},
+		},
+	}
+
+	// setup tests
+	tests := []struct {
+		name         string
+		pipelineType string
+		file         string
+	}{
+		{"go", constants.PipelineTypeGo, "testdata/vars/go/.vela.yml"},
+		{"starlark", constants.PipelineTypeStarlark, "testdata/vars/starlark/.vela.star"},
+	}
+
+	// run tests
+	for _, test := range tests {
+		pipelineType := test.pipelineType
+
+		c := &client{
+			repo: &library.Repo{PipelineType: &pipelineType},
+		}
+
+		// the registry from the vars file is overridden by the vars from the engine
+		c.WithVars(map[string]interface{}{"registry": "foo"})
+
+		got, err := c.Parse(test.file)
+		if err != nil {
+			t.Errorf("Parse for %s returned err: %v", test.name, err)
+		}
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Parse for %s mismatch (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
 func Test_client_ParseRaw(t *testing.T) {
 	expected, err := ioutil.ReadFile("testdata/metadata.yml")
 	if err != nil {
@@ -0,0 +1,315 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/go-vela/compiler/compiler"
+
+	types "github.com/go-vela/types/yaml"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// lineRegex matches the line number reported in yaml errors.
+var lineRegex = regexp.MustCompile(`line (\d+)`)
+
+// positions represents the locations of the nodes
+// in the original yaml configuration captured while
+// parsing the configuration.
+//
+// The nodes are keyed by the pointers for the parsed stages,
+// steps, services, secrets and templates so the locations
+// are retained while the compiler modifies the pipeline.
+type positions struct {
+	file  string
+	root  *yaml3.Node
+	nodes map[interface{}]*yaml3.Node
+	// files contains the names of the files for the
+	// nodes merged from other yaml configurations
+	files map[interface{}]string
+}
+
+// newPositions is a helper function that captures the locations
+// of the nodes for the parsed yaml configuration from the bytes.
+//
+// The build may be nil when only the lines and columns of the
+// yaml configuration are needed, i.e. to locate a parse error.
+func newPositions(file string, b []byte, p *types.Build) *positions {
+	pos := &positions{
+		file:  file,
+		nodes: make(map[interface{}]*yaml3.Node),
+	}
+
+	doc := new(yaml3.Node)
+
+	// parse the bytes into a tree of yaml nodes
+	err := yaml3.Unmarshal(b, doc)
+	if err != nil || len(doc.Content) == 0 {
+		return pos
+	}
+
+	pos.root = resolve(doc.Content[0])
+
+	// check if the parsed yaml configuration was provided
+	if p == nil {
+		return pos
+	}
+
+	// capture the nodes for the parsed steps
+	pos.steps(mappingValue(pos.root, "steps"), p.Steps)
+
+	// capture the nodes for the parsed services
+	if n := mappingValue(pos.root, "services"); n != nil && len(n.Content) == len(p.Services) {
+		for i, service := range p.Services {
+			pos.nodes[service] = resolve(n.Content[i])
+		}
+	}
+
+	// capture the nodes for the parsed secrets
+	if n := mappingValue(pos.root, "secrets"); n != nil && len(n.Content) == len(p.Secrets) {
+		for i, secret := range p.Secrets {
+			pos.nodes[secret] = resolve(n.Content[i])
+		}
+	}
+
+	// capture the nodes for the parsed templates
+	if n := mappingValue(pos.root, "templates"); n != nil && len(n.Content) == len(p.Templates) {
+		for i, tmpl := range p.Templates {
+			pos.nodes[tmpl] = resolve(n.Content[i])
+		}
+	}
+
+	// capture the nodes for the parsed stages
+	//
+	// stages are declared as a map in the yaml configuration
+	// so the content contains a key and value for each stage
+	if n := mappingValue(pos.root, "stages"); n != nil && len(n.Content) == 2*len(p.Stages) {
+		for i, stage := range p.Stages {
+			node := resolve(n.Content[2*i+1])
+
+			pos.nodes[stage] = node
+
+			pos.steps(mappingValue(node, "steps"), stage.Steps)
+		}
+	}
+
+	return pos
+}
+
+// steps is a helper function that captures
+// the nodes for the parsed steps.
+func (p *positions) steps(n *yaml3.Node, s types.StepSlice) {
+	if n == nil || len(n.Content) != len(s) {
+		return
+	}
+
+	for i, step := range s {
+		p.nodes[step] = resolve(n.Content[i])
+	}
+}
+
+// node returns the yaml node captured
+// for the parsed object if it exists.
+func (p *positions) node(v interface{}) *yaml3.Node {
+	if p == nil {
+		return nil
+	}
+
+	return p.nodes[v]
+}
+
+// position returns the location of the field for the parsed
+// object, falling back to the location of the object itself
+// when the field isn't declared. The field may be empty.
+func (p *positions) position(v interface{}, field string) *compiler.Position {
+	n := p.node(v)
+	if n == nil {
+		return nil
+	}
+
+	pos := p.at(n.Line, n.Column)
+
+	// check if the field is declared for the object
+	if k := mappingKey(n, field); k != nil {
+		pos = p.at(k.Line, k.Column)
+	}
+
+	// check if the object was merged from another yaml configuration
+	if file, ok := p.files[v]; ok {
+		pos.File = file
+	}
+
+	return pos
+}
+
+// merge captures the locations of the nodes for
+// the parsed objects from another yaml configuration.
+func (p *positions) merge(other *positions) {
+	if p == nil || other == nil {
+		return
+	}
+
+	if p.files == nil {
+		p.files = make(map[interface{}]string)
+	}
+
+	for v, n := range other.nodes {
+		p.nodes[v] = n
+		p.files[v] = other.file
+	}
+}
+
+// alias captures the location of the original
+// parsed object for the copy of the object.
+func (p *positions) alias(cp, original interface{}) {
+	n := p.node(original)
+	if n == nil {
+		return
+	}
+
+	p.nodes[cp] = n
+
+	if file, ok := p.files[original]; ok {
+		p.files[cp] = file
+	}
+}
+
+// wrapNode returns the error with the location of the node,
+// or the original error if the node can't be located.
+func (p *positions) wrapNode(err error, n *yaml3.Node) error {
+	if n == nil {
+		return err
+	}
+
+	return &compiler.PositionError{Position: p.at(n.Line, n.Column), Err: err}
+}
+
+// top returns the location of the top level field
+// for the yaml configuration if it is declared.
+func (p *positions) top(field string) *compiler.Position {
+	if p == nil || p.root == nil {
+		return nil
+	}
+
+	k := mappingKey(p.root, field)
+	if k == nil {
+		return nil
+	}
+
+	return p.at(k.Line, k.Column)
+}
+
+// wrap returns the error with the location of the field for the
+// parsed object, or the original error if it can't be located.
+func (p *positions) wrap(err error, v interface{}, field string) error {
+	pos := p.position(v, field)
+	if pos == nil {
+		return err
+	}
+
+	return &compiler.PositionError{Position: pos, Err: err}
+}
+
+// wrapLine returns the error with the location for the line
+// number reported in the error, or the original error if
+// a line number isn't reported.
+func (p *positions) wrapLine(err error) error {
+	match := lineRegex.FindStringSubmatch(err.Error())
+	if len(match) < 2 {
+		return err
+	}
+
+	line, _ := strconv.Atoi(match[1])
+
+	return &compiler.PositionError{Position: p.at(line, p.column(line)), Err: err}
+}
+
+// at is a helper function that creates the
+// location for the line and column provided.
+func (p *positions) at(line, column int) *compiler.Position {
+	file := ""
+	if p != nil {
+		file = p.file
+	}
+
+	return &compiler.Position{
+		File:   file,
+		Line:   line,
+		Column: column,
+	}
+}
+
+// column is a helper function that returns the column for
+// the first node on the line provided, or 0 if no node exists.
+func (p *positions) column(line int) int {
+	if p == nil || p.root == nil {
+		return 0
+	}
+
+	return firstColumn(p.root, line)
+}
+
+// firstColumn is a helper function that walks the yaml nodes
+// to find the column for the first node on the line provided.
+func firstColumn(n *yaml3.Node, line int) int {
+	if n.Line == line {
+		return n.Column
+	}
+
+	for _, c := range n.Content {
+		if col := firstColumn(c, line); col > 0 {
+			return col
+		}
+	}
+
+	return 0
+}
+
+// mappingKey is a helper function that returns the
+// key node for the field in the mapping node provided.
+func mappingKey(n *yaml3.Node, field string) *yaml3.Node {
+	if n == nil || n.Kind != yaml3.MappingNode || len(field) == 0 {
+		return nil
+	}
+
+	// mapping nodes contain the key followed by the value for each field
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == field {
+			return n.Content[i]
+		}
+	}
+
+	return nil
+}
+
+// mappingValue is a helper function that returns the
+// value node for the field in the mapping node provided.
+func mappingValue(n *yaml3.Node, field string) *yaml3.Node {
+	if n == nil || n.Kind != yaml3.MappingNode {
+		return nil
+	}
+
+	// mapping nodes contain the key followed by the value for each field
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == field {
+			return resolve(n.Content[i+1])
+		}
+	}
+
+	return nil
+}
+
+// resolve is a helper function that returns the
+// node referenced by an alias node in the yaml.
+func resolve(n *yaml3.Node) *yaml3.Node {
+	for n != nil && n.Kind == yaml3.AliasNode {
+		n = n.Alias
+	}
+
+	return n
+}
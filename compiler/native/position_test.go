@@ -0,0 +1,183 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/go-vela/compiler/compiler"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/urfave/cli/v2"
+)
+
+const positionsYAML = `version: "1"
+
+services:
+  - name: postgres
+    image: postgres:12
+
+stages:
+  test:
+    steps:
+      - name: install
+        image: golang:latest
+        commands:
+          - go get ./...
+
+      - name: test
+        commands:
+          - go test ./...
+`
+
+func TestNative_positions(t *testing.T) {
+	// setup types
+	p, err := ParseString(positionsYAML)
+	if err != nil {
+		t.Errorf("ParseString returned err: %v", err)
+	}
+
+	pos := newPositions(".vela.yml", []byte(positionsYAML), p)
+
+	// setup tests
+	tests := []struct {
+		name string
+		got  *compiler.Position
+		want *compiler.Position
+	}{
+		{
+			name: "top level field",
+			got:  pos.top("version"),
+			want: &compiler.Position{File: ".vela.yml", Line: 1, Column: 1},
+		},
+		{
+			name: "service field",
+			got:  pos.position(p.Services[0], "image"),
+			want: &compiler.Position{File: ".vela.yml", Line: 5, Column: 5},
+		},
+		{
+			name: "stage field",
+			got:  pos.position(p.Stages[0], "steps"),
+			want: &compiler.Position{File: ".vela.yml", Line: 9, Column: 5},
+		},
+		{
+			name: "step field",
+			got:  pos.position(p.Stages[0].Steps[0], "commands"),
+			want: &compiler.Position{File: ".vela.yml", Line: 12, Column: 9},
+		},
+		{
+			name: "step missing field",
+			got:  pos.position(p.Stages[0].Steps[1], "image"),
+			want: &compiler.Position{File: ".vela.yml", Line: 15, Column: 9},
+		},
+		{
+			name: "unknown object",
+			got:  pos.position("foo", "image"),
+			want: nil,
+		},
+		{
+			name: "nil positions",
+			got:  (*positions)(nil).position(p.Stages[0], "steps"),
+			want: nil,
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, test.got); diff != "" {
+				t.Errorf("position() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNative_positions_wrapLine(t *testing.T) {
+	// setup types
+	pos := newPositions("", []byte(positionsYAML), nil)
+
+	want := &compiler.Position{Line: 11, Column: 9}
+
+	// run test
+	err := pos.wrapLine(fmt.Errorf("yaml: line 11: cannot unmarshal"))
+
+	var got *compiler.PositionError
+	if !errors.As(err, &got) {
+		t.Errorf("wrapLine returned err %v, want PositionError", err)
+
+		return
+	}
+
+	if diff := cmp.Diff(want, got.Position); diff != "" {
+		t.Errorf("wrapLine() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNative_Parse_Position(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	b := []byte(`version: "1"
+
+steps:
+  - name: test
+    image: [ "alpine" ]
+`)
+
+	var got *compiler.PositionError
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	_, err = compiler.Parse(b)
+	if !errors.As(err, &got) {
+		t.Errorf("Parse returned err %v, want PositionError", err)
+
+		return
+	}
+
+	if got.Position.Line != 5 {
+		t.Errorf("Parse returned line %d, want %d", got.Position.Line, 5)
+	}
+}
+
+func TestNative_Validate_Position(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	want := &compiler.Position{File: "", Line: 15, Column: 9}
+
+	var got *compiler.ValidationError
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	p, err := compiler.Parse(positionsYAML)
+	if err != nil {
+		t.Errorf("Parse returned err: %v", err)
+	}
+
+	err = compiler.Validate(p)
+	if !errors.As(err, &got) {
+		t.Errorf("Validate returned err %v, want ValidationError", err)
+
+		return
+	}
+
+	if diff := cmp.Diff(want, got.Problems[0].Position); diff != "" {
+		t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-vela/compiler/compiler"
+	"github.com/go-vela/compiler/registry"
+	"github.com/go-vela/compiler/registry/cache"
+
+	"github.com/go-vela/types/library"
+	"github.com/sirupsen/logrus"
+)
+
+// WithRegistry sets the template service used for
+// templates of the type provided in the Engine.
+//
+// The type is case insensitive and replaces any existing
+// service for the type. The service is wrapped with a
+// cache when the template cache is configured, reusing
+// the cache for the existing service for the type.
+func (c *client) WithRegistry(name string, svc registry.Service) compiler.Engine {
+	if len(name) != 0 && svc != nil {
+		if c.Registries == nil {
+			c.Registries = make(map[string]registry.Service)
+		}
+
+		// check if the existing service for the type is cached
+		//
+		// the cache is shared with the duplicates of the client
+		// so the templates survive refreshing the service per build
+		if existing, ok := c.Registries[strings.ToLower(name)].(cachedService); ok {
+			c.Registries[strings.ToLower(name)] = existing.WithService(svc)
+
+			return c
+		}
+
+		// check if the template cache is configured
+		if c.TemplateCache.TTL > 0 {
+			cached, err := setupCache(name, svc, c.TemplateCache)
+			if err != nil {
+				logrus.Errorf("unable to setup template cache for %s: %v", name, err)
+			} else {
+				svc = cached
+			}
+		}
+
+		c.Registries[strings.ToLower(name)] = svc
+	}
+
+	return c
+}
+
+// cachedService represents a template service that
+// caches the templates captured from another service.
+type cachedService interface {
+	WithService(registry.Service) registry.Service
+}
+
+// setupCache is a helper function to setup the cache
+// for the registry service from the CLI arguments.
+//
+// Templates for each type are persisted in a separate
+// directory when the cache directory is provided.
+//
+// nolint: lll // ignore long line length due to parameters
+func setupCache(name string, svc registry.Service, cfg TemplateCacheConfig) (registry.Service, error) {
+	logrus.Tracef("Creating template cache for %s registry client from CLI configuration", name)
+
+	dir := cfg.Directory
+	if len(dir) > 0 {
+		dir = filepath.Join(dir, strings.ToLower(name))
+	}
+
+	return cache.New(svc, cfg.TTL, cfg.Size, dir)
+}
+
+// registry is a helper function that returns the
+// template service for the template type provided.
+func (c *client) registry(name string) (registry.Service, error) {
+	svc, ok := c.Registries[strings.ToLower(name)]
+	if !ok || svc == nil {
+		return nil, fmt.Errorf("unsupported template type %q", name)
+	}
+
+	return svc, nil
+}
+
+// githubRegistry is a helper function that creates the template
+// service for github templates from the github clients.
+func (c *client) githubRegistry() registry.Service {
+	return &githubRegistry{
+		public:     c.Github,
+		private:    c.PrivateGithub,
+		usePrivate: c.UsePrivateGithub,
+	}
+}
+
+// githubRegistry represents the template service for github
+// templates that pulls from the public or private github
+// instance depending on the host for the template.
+type githubRegistry struct {
+	public     registry.Service
+	private    registry.Service
+	usePrivate bool
+}
+
+// Parse creates the registry source object from a template path.
+func (r *githubRegistry) Parse(path string) (*registry.Source, error) {
+	return r.public.Parse(path)
+}
+
+// Shared reports if the template is captured from the public github
+// instance without auth, i.e. the host isn't provided or is set to
+// github.com, so the template is the same for every user.
+func (r *githubRegistry) Shared(s *registry.Source) bool {
+	if r.private == nil {
+		return true
+	}
+
+	return !r.usePrivate && (len(s.Host) == 0 || strings.Contains(s.Host, "github.com"))
+}
+
+// Template captures the templated pipeline configuration from the repo.
+func (r *githubRegistry) Template(u *library.User, s *registry.Source) ([]byte, error) {
+	return r.TemplateWithContext(context.Background(), u, s)
+}
+
+// TemplateWithContext captures the templated pipeline configuration
+// from the repo using the provided context for the API call.
+//
+// nolint: lll // ignore long line length due to parameters
+func (r *githubRegistry) TemplateWithContext(ctx context.Context, u *library.User, s *registry.Source) ([]byte, error) {
+	// pull from github without auth when the host isn't provided or is set to github.com
+	if r.Shared(s) {
+		return registry.TemplateWithContext(ctx, r.public, nil, s)
+	}
+
+	// use private (authenticated) github instance to pull from
+	return registry.TemplateWithContext(ctx, r.private, u, s)
+}
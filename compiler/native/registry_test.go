@@ -0,0 +1,260 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-vela/compiler/registry"
+
+	"github.com/go-vela/types/library"
+	"github.com/go-vela/types/yaml"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/urfave/cli/v2"
+)
+
+// fakeRegistry represents a template service
+// that returns the same template for every source.
+type fakeRegistry struct {
+	name     string
+	template string
+	sources  []*registry.Source
+}
+
+func (r *fakeRegistry) Parse(path string) (*registry.Source, error) {
+	return &registry.Source{Host: r.name, Name: path}, nil
+}
+
+func (r *fakeRegistry) Template(u *library.User, s *registry.Source) ([]byte, error) {
+	return r.TemplateWithContext(context.Background(), u, s)
+}
+
+// nolint: lll // ignore long line length due to parameters
+func (r *fakeRegistry) TemplateWithContext(ctx context.Context, u *library.User, s *registry.Source) ([]byte, error) {
+	r.sources = append(r.sources, s)
+
+	return []byte(r.template), nil
+}
+
+func TestNative_WithRegistry(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	svc := &fakeRegistry{
+		name: "internal",
+		template: `steps:
+  - name: test
+    image: alpine
+    commands: [ echo hello ]
+`,
+	}
+
+	tmpls := map[string]*yaml.Template{
+		"internal": {
+			Name:   "internal",
+			Source: "templates/internal.yml",
+			Type:   "Internal",
+		},
+	}
+
+	steps := yaml.StepSlice{
+		&yaml.Step{
+			Name: "sample",
+			Template: yaml.StepTemplate{
+				Name: "internal",
+			},
+		},
+	}
+
+	want := []*registry.Source{
+		{
+			Host: "internal",
+			Name: "templates/internal.yml",
+		},
+	}
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating new compiler returned err: %v", err)
+	}
+
+	// the registry should not be available on the original compiler
+	duplicate := compiler.Duplicate().WithRegistry("internal", svc)
+
+	_, _, _, _, err = compiler.ExpandSteps(&yaml.Build{Steps: steps}, tmpls)
+	if err == nil {
+		t.Errorf("ExpandSteps should have returned err")
+	}
+
+	got, _, _, _, err := duplicate.ExpandSteps(&yaml.Build{Steps: steps}, tmpls)
+	if err != nil {
+		t.Errorf("ExpandSteps returned err: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Name != "sample_test" {
+		t.Errorf("ExpandSteps is %v, want sample_test", got)
+	}
+
+	if diff := cmp.Diff(want, svc.sources); diff != "" {
+		t.Errorf("WithRegistry() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNative_githubRegistry(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		usePrivate bool
+		host       string
+		want       string
+	}{
+		{usePrivate: false, host: "", want: "public"},
+		{usePrivate: false, host: "github.com", want: "public"},
+		{usePrivate: false, host: "git.example.com", want: "private"},
+		{usePrivate: true, host: "github.com", want: "private"},
+	}
+
+	// run tests
+	for _, test := range tests {
+		public := &fakeRegistry{name: "public", template: "public"}
+		private := &fakeRegistry{name: "private", template: "private"}
+
+		c := &client{
+			Github:           public,
+			PrivateGithub:    private,
+			UsePrivateGithub: test.usePrivate,
+		}
+
+		svc := c.githubRegistry()
+		src := &registry.Source{Host: test.host}
+
+		got, err := svc.Template(nil, src)
+		if err != nil {
+			t.Errorf("Template returned err: %v", err)
+		}
+
+		if string(got) != test.want {
+			t.Errorf("Template for host %s is %s, want %s", test.host, got, test.want)
+		}
+
+		// only the templates pulled without auth are shared between users
+		shared := registry.Shared(svc, src)
+
+		if shared != (test.want == "public") {
+			t.Errorf("Shared for host %s is %v, want %v", test.host, shared, !shared)
+		}
+	}
+}
+
+func TestNative_WithRegistry_TemplateCache(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	resp := httptest.NewRecorder()
+	_, engine := gin.CreateTestContext(resp)
+
+	count := 0
+
+	// setup mock server
+	engine.GET("/api/v3/repos/foo/bar/contents/:path", func(c *gin.Context) {
+		count++
+
+		c.Header("Content-Type", "application/json")
+		c.Status(http.StatusOK)
+		c.File("testdata/template.json")
+	})
+
+	s := httptest.NewServer(engine)
+	defer s.Close()
+
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.Bool("github-driver", true, "doc")
+	set.String("github-url", s.URL, "doc")
+	set.String("github-token", "", "doc")
+	set.Duration("template-cache-ttl", time.Minute, "doc")
+	set.Int("template-cache-size", 10, "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	tmpls := map[string]*yaml.Template{
+		"gradle": {
+			Name:   "gradle",
+			Source: "github.example.com/foo/bar/template.yml@v1.2.0",
+			Type:   "github",
+		},
+	}
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating new compiler returned err: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		steps := yaml.StepSlice{
+			&yaml.Step{
+				Name: "sample",
+				Template: yaml.StepTemplate{
+					Name: "gradle",
+					Variables: map[string]interface{}{
+						"image":       "openjdk:latest",
+						"environment": "{ GRADLE_USER_HOME: .gradle }",
+						"pull_policy": "pull: true",
+					},
+				},
+			},
+		}
+
+		// templates should be cached between duplicated compilers
+		_, _, _, _, err = compiler.Duplicate().ExpandSteps(&yaml.Build{Steps: steps}, tmpls)
+		if err != nil {
+			t.Errorf("ExpandSteps returned err: %v", err)
+		}
+	}
+
+	if count != 1 {
+		t.Errorf("ExpandSteps fetched template %d times, want %d", count, 1)
+	}
+
+	// templates should be cached per user when refreshing the private github client
+	for _, token := range []string{"foo", "foo", "bar"} {
+		u := new(library.User)
+		u.SetToken(token)
+
+		steps := yaml.StepSlice{
+			&yaml.Step{
+				Name: "sample",
+				Template: yaml.StepTemplate{
+					Name: "gradle",
+					Variables: map[string]interface{}{
+						"image":       "openjdk:latest",
+						"environment": "{ GRADLE_USER_HOME: .gradle }",
+						"pull_policy": "pull: true",
+					},
+				},
+			},
+		}
+
+		_, _, _, _, err = compiler.Duplicate().
+			WithPrivateGitHub(s.URL, "baz").
+			WithUser(u).
+			ExpandSteps(&yaml.Build{Steps: steps}, tmpls)
+		if err != nil {
+			t.Errorf("ExpandSteps returned err: %v", err)
+		}
+	}
+
+	if count != 3 {
+		t.Errorf("ExpandSteps fetched template %d times, want %d", count, 3)
+	}
+}
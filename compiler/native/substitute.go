@@ -12,6 +12,8 @@ import (
 
 	"github.com/drone/envsubst"
 
+	"github.com/go-vela/compiler/compiler"
+
 	types "github.com/go-vela/types/yaml"
 )
 
@@ -22,7 +24,7 @@ func (c *client) SubstituteStages(s types.StageSlice) (types.StageSlice, error)
 	// iterate through all stages
 	for _, stage := range s {
 		// inject the scripts into the steps for the stage
-		steps, err := c.SubstituteSteps(stage.Steps)
+		steps, err := c.substituteSteps(stage.Steps, stage.Name)
 		if err != nil {
 			return nil, err
 		}
@@ -36,13 +38,33 @@ func (c *client) SubstituteStages(s types.StageSlice) (types.StageSlice, error)
 // SubstituteSteps replaces every declared environment
 // variable with it's corresponding value for each step
 // in a yaml configuration.
+//
+// A reference is escaped with an additional $ to produce
+// a literal in any field of the step, i.e. $${FOO} is
+// replaced with ${FOO} without looking up the variable.
+//
+// The references to environment variables that aren't declared
+// for the step are left unchanged, captured as a warning or
+// fail the compile based off the mode for the substitution.
 func (c *client) SubstituteSteps(s types.StepSlice) (types.StepSlice, error) {
+	return c.substituteSteps(s, "")
+}
+
+// substituteSteps is a helper function that replaces every declared
+// environment variable with it's corresponding value for each step
+// in a yaml configuration. The stage is empty when the steps are
+// not part of a stage.
+//
+// nolint: funlen // ignore function length due to comments
+func (c *client) substituteSteps(s types.StepSlice, stage string) (types.StepSlice, error) {
 	// iterate through all steps
 	for _, step := range s {
 		// marshal step configuration
 		body, err := yaml.Marshal(step)
 		if err != nil {
-			return nil, fmt.Errorf("unable to marshal configuration: %v", err)
+			err = fmt.Errorf("unable to marshal configuration: %v", err)
+
+			return nil, c.positions.wrap(err, step, "")
 		}
 
 		// create substitute function
@@ -55,6 +77,10 @@ func (c *client) SubstituteSteps(s types.StepSlice) (types.StepSlice, error) {
 				return fmt.Sprintf("${%s}", name)
 			}
 
+			// unescape the environment variable to match the
+			// value substituted in the environment for the step
+			env = strings.ReplaceAll(env, "$$", "$")
+
 			// check for a new line
 			if strings.Contains(env, "\n") {
 				// escape the environment variable
@@ -67,15 +93,94 @@ func (c *client) SubstituteSteps(s types.StepSlice) (types.StepSlice, error) {
 		// substitute the environment variables
 		subStep, err := envsubst.Eval(string(body), subFunc)
 		if err != nil {
-			return nil, fmt.Errorf("unable to substitute environment variables: %v", err)
+			err = fmt.Errorf("unable to substitute environment variables: %v", err)
+
+			return nil, c.positions.wrap(err, step, "")
+		}
+
+		// capture the referenced variables that aren't declared
+		unknown, err := c.unknownVariables(step)
+		if err != nil {
+			return nil, c.positions.wrap(err, step, "")
+		}
+
+		switch c.SubstituteMode {
+		case SubstituteStrict:
+			if len(unknown) > 0 {
+				// nolint: lll // ignore long line length due to error message
+				err = fmt.Errorf("step %s references undeclared environment variables: %s", step.Name, strings.Join(unknown, ", "))
+
+				return nil, c.positions.wrap(err, step, "")
+			}
+		case SubstituteWarn:
+			for _, name := range unknown {
+				c.warn(&compiler.Warning{
+					Code:    compiler.WarningUnknownVariable,
+					Stage:   stage,
+					Step:    step.Name,
+					Message: fmt.Sprintf("step %s references undeclared environment variable %s", step.Name, name),
+				}, step, "")
+			}
 		}
 
 		// unmarshal step configuration
 		err = yaml.Unmarshal([]byte(subStep), step)
 		if err != nil {
-			return nil, fmt.Errorf("unable to unmarshal configuration: %v", err)
+			err = fmt.Errorf("unable to unmarshal configuration: %v", err)
+
+			return nil, c.positions.wrap(err, step, "")
 		}
 	}
 
 	return s, nil
 }
+
+// unknownVariables is a helper function that returns the environment
+// variables referenced by the step that aren't declared for the step.
+//
+// The values of the environment for the step are never checked since
+// they are injected from the build, i.e. the commit message, and can
+// contain references that aren't meant to be substituted.
+func (c *client) unknownVariables(step *types.Step) ([]string, error) {
+	// skip checking the references when they're left unchanged
+	if c.SubstituteMode != SubstituteStrict && c.SubstituteMode != SubstituteWarn {
+		return nil, nil
+	}
+
+	// copy the step without the environment
+	cp := *step
+	cp.Environment = nil
+
+	body, err := yaml.Marshal(&cp)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal configuration: %v", err)
+	}
+
+	// unknown contains the referenced variables that aren't declared
+	unknown := []string{}
+
+	_, err = envsubst.Eval(string(body), func(name string) string {
+		if _, ok := step.Environment[name]; !ok {
+			unknown = appendUnique(unknown, name)
+		}
+
+		return ""
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to substitute environment variables: %v", err)
+	}
+
+	return unknown, nil
+}
+
+// helper function that appends the value to the slice
+// unless the slice already contains the value.
+func appendUnique(s []string, value string) []string {
+	for _, v := range s {
+		if v == value {
+			return s
+		}
+	}
+
+	return append(s, value)
+}
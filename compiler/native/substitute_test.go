@@ -7,10 +7,13 @@ package native
 import (
 	"flag"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/go-vela/types/library"
 	"github.com/go-vela/types/yaml"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/urfave/cli/v2"
 )
 
@@ -181,3 +184,178 @@ func TestNative_SubstituteSteps(t *testing.T) {
 		t.Errorf("SubstituteSteps is %v, want %v", got, want)
 	}
 }
+
+func TestNative_SubstituteSteps_Escape(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	p := yaml.StepSlice{
+		{
+			Commands:    []string{"echo $${HOME}", "echo ${PRICE}", "echo $$PWD"},
+			Entrypoint:  []string{"/bin/sh", "-c", "echo $${SHELL}"},
+			Environment: map[string]string{"PRICE": "$$5", "SCRIPT": "$${HOME}/run.sh"},
+			Image:       "alpine:$${TAG}",
+			Name:        "escape",
+			Parameters:  map[string]interface{}{"path": "$${GOPATH}/bin"},
+			Pull:        "always",
+		},
+	}
+
+	want := yaml.StepSlice{
+		{
+			Commands:    []string{"echo ${HOME}", "echo $5", "echo $PWD"},
+			Entrypoint:  []string{"/bin/sh", "-c", "echo ${SHELL}"},
+			Environment: map[string]string{"PRICE": "$5", "SCRIPT": "${HOME}/run.sh"},
+			Image:       "alpine:${TAG}",
+			Name:        "escape",
+			Parameters:  map[string]interface{}{"path": "${GOPATH}/bin"},
+			Pull:        "always",
+		},
+	}
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.SubstituteMode = SubstituteStrict
+
+	// run test
+	got, err := compiler.SubstituteSteps(p)
+	if err != nil {
+		t.Errorf("SubstituteSteps returned err: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SubstituteSteps mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNative_SubstituteSteps_Mode(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		mode         string
+		wantErr      string
+		wantWarnings []string
+	}{
+		{
+			mode: SubstituteLenient,
+		},
+		{
+			mode: SubstituteWarn,
+			wantWarnings: []string{
+				"line 4, column 5: step test references undeclared environment variable NOT_FOUND",
+				"line 4, column 5: step test references undeclared environment variable TYPO",
+			},
+		},
+		{
+			mode:    SubstituteStrict,
+			wantErr: "line 4, column 5: step test references undeclared environment variables: NOT_FOUND, TYPO",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		config := `version: "1"
+
+steps:
+  - name: test
+    image: alpine
+    environment:
+      FOO: bar
+    commands:
+      - echo ${FOO} ${NOT_FOUND}
+      - echo ${TYPO} ${NOT_FOUND} $${ESCAPED}
+`
+
+		set := flag.NewFlagSet("test", 0)
+		set.String("substitute-mode", test.mode, "doc")
+		c := cli.NewContext(nil, set, nil)
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Creating compiler returned err: %v", err)
+		}
+
+		p, err := compiler.Parse([]byte(config))
+		if err != nil {
+			t.Errorf("Parse returned err: %v", err)
+		}
+
+		got, err := compiler.SubstituteSteps(p.Steps)
+
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("SubstituteSteps for %s returned err %v, want %s", test.mode, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("SubstituteSteps for %s returned err: %v", test.mode, err)
+		}
+
+		want := []string{"echo bar ${NOT_FOUND}", "echo ${TYPO} ${NOT_FOUND} ${ESCAPED}"}
+
+		if diff := cmp.Diff(want, []string(got[0].Commands)); diff != "" {
+			t.Errorf("SubstituteSteps for %s mismatch (-want +got):\n%s", test.mode, diff)
+		}
+
+		warnings := []string{}
+		for _, w := range compiler.Warnings() {
+			warnings = append(warnings, w.String())
+		}
+
+		if len(test.wantWarnings) == 0 {
+			test.wantWarnings = []string{}
+		}
+
+		if diff := cmp.Diff(test.wantWarnings, warnings); diff != "" {
+			t.Errorf("Warnings for %s mismatch (-want +got):\n%s", test.mode, diff)
+		}
+	}
+}
+
+func TestNative_SubstituteSteps_Strict_CommitMessage(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.String("substitute-mode", SubstituteStrict, "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	b := new(library.Build)
+	b.SetEvent("push")
+	b.SetBranch("master")
+	b.SetMessage("fix ${WHATEVER} handling")
+
+	config := `version: "1"
+
+steps:
+  - name: test
+    image: alpine
+    commands:
+      - echo ${VELA_BUILD_BRANCH}
+`
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.WithBuild(b).WithLocal(true)
+
+	// run test
+	//
+	// references in the injected environment, i.e. the commit message, are never checked
+	_, err = compiler.Compile([]byte(config))
+	if err != nil {
+		t.Errorf("Compile returned err: %v", err)
+	}
+
+	// references declared by the step are still checked
+	_, err = compiler.Compile([]byte(config + "      - echo ${WHATEVER}\n"))
+	if err == nil || !strings.Contains(err.Error(), "references undeclared environment variables: WHATEVER") {
+		t.Errorf("Compile returned err %v, want undeclared WHATEVER", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"sort"
+
+	"github.com/go-vela/compiler/compiler"
+)
+
+// tracer captures where each environment
+// variable for a step was declared.
+type tracer struct {
+	vars map[string]*compiler.EnvironmentVariable
+}
+
+// newTracer returns a tracer for the environment of a step.
+func newTracer() *tracer {
+	return &tracer{
+		vars: make(map[string]*compiler.EnvironmentVariable),
+	}
+}
+
+// set captures the value for the environment variable from the
+// source and the value it overwrites. The tracer may be nil.
+func (t *tracer) set(name, value string, source compiler.EnvironmentSource) {
+	if t == nil {
+		return
+	}
+
+	v, ok := t.vars[name]
+	if !ok {
+		t.vars[name] = &compiler.EnvironmentVariable{
+			Name:   name,
+			Source: source,
+			Value:  value,
+		}
+
+		return
+	}
+
+	v.Shadowed = append(v.Shadowed, &compiler.EnvironmentValue{
+		Source: v.Source,
+		Value:  v.Value,
+	})
+
+	v.Source = source
+	v.Value = value
+}
+
+// setAll captures the values for the environment
+// variables from the source in a sorted order.
+func (t *tracer) setAll(env map[string]string, source compiler.EnvironmentSource) {
+	if t == nil {
+		return
+	}
+
+	keys := []string{}
+
+	for k := range env {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		t.set(k, env[k], source)
+	}
+}
+
+// trace returns the captured environment
+// variables for the step sorted by name.
+func (t *tracer) trace(stage, step string) *compiler.EnvironmentTrace {
+	trace := &compiler.EnvironmentTrace{
+		Stage:     stage,
+		Step:      step,
+		Variables: []*compiler.EnvironmentVariable{},
+	}
+
+	for _, v := range t.vars {
+		trace.Variables = append(trace.Variables, v)
+	}
+
+	sort.Slice(trace.Variables, func(i, j int) bool {
+		return trace.Variables[i].Name < trace.Variables[j].Name
+	})
+
+	return trace
+}
+
+// WithEnvironmentTrace sets whether the Engine captures where each
+// environment variable for the compiled steps was declared.
+func (c *client) WithEnvironmentTrace(enabled bool) compiler.Engine {
+	c.traceEnv = enabled
+
+	return c
+}
+
+// EnvironmentTrace returns where each environment variable for the steps
+// compiled from the last yaml configuration was declared when tracing
+// is enabled with WithEnvironmentTrace.
+func (c *client) EnvironmentTrace() []*compiler.EnvironmentTrace {
+	return c.traces
+}
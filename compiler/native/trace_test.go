@@ -0,0 +1,163 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/go-vela/compiler/compiler"
+
+	"github.com/go-vela/types/library"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/urfave/cli/v2"
+)
+
+func TestNative_Compile_EnvironmentTrace(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	b := new(library.Build)
+	b.SetEvent("push")
+
+	config := `version: "1"
+metadata:
+  clone: false
+environment:
+  REGION: us-east-1
+  VELA_BUILD_EVENT: pull_request
+steps:
+  - name: build
+    image: alpine
+    environment:
+      REGION: us-west-2
+      VELA_BUILD_EVENT: custom
+    parameters:
+      debug: true
+`
+
+	want := map[string]*compiler.EnvironmentVariable{
+		"REGION": {
+			Name:   "REGION",
+			Source: compiler.SourceStep,
+			Value:  "us-west-2",
+			Shadowed: []*compiler.EnvironmentValue{
+				{Source: compiler.SourceGlobal, Value: "us-east-1"},
+			},
+		},
+		"VELA_BUILD_EVENT": {
+			Name:   "VELA_BUILD_EVENT",
+			Source: compiler.SourcePlatform,
+			Value:  "push",
+			Shadowed: []*compiler.EnvironmentValue{
+				{Source: compiler.SourceGlobal, Value: "pull_request"},
+				{Source: compiler.SourceStep, Value: "custom"},
+			},
+		},
+		"PARAMETER_DEBUG": {
+			Name:   "PARAMETER_DEBUG",
+			Source: compiler.SourceParameter,
+			Value:  "true",
+		},
+		"VELA_BUILD_NUMBER": {
+			Name:   "VELA_BUILD_NUMBER",
+			Source: compiler.SourcePlatform,
+			Value:  "0",
+		},
+	}
+
+	got := make(map[string]*compiler.EnvironmentVariable)
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.WithBuild(b).WithEnvironmentTrace(true)
+
+	// run test
+	_, err = compiler.Compile([]byte(config))
+	if err != nil {
+		t.Errorf("Compile returned err: %v", err)
+	}
+
+	traces := compiler.EnvironmentTrace()
+
+	// the init step is injected before the declared steps
+	if len(traces) != 2 || traces[0].Step != "init" || traces[1].Step != "build" {
+		t.Errorf("EnvironmentTrace is %v, want traces for init and build", traces)
+
+		return
+	}
+
+	for i, v := range traces[1].Variables {
+		if _, ok := want[v.Name]; ok {
+			got[v.Name] = v
+		}
+
+		// the variables are sorted by name
+		if i > 0 && traces[1].Variables[i-1].Name >= v.Name {
+			t.Errorf("EnvironmentTrace variables are not sorted at %s", v.Name)
+		}
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("EnvironmentTrace mismatch (-want +got):\n%s", diff)
+	}
+
+	// the trace is only captured when tracing is enabled
+	compiler.WithEnvironmentTrace(false)
+
+	_, err = compiler.Compile([]byte(config))
+	if err != nil {
+		t.Errorf("Compile returned err: %v", err)
+	}
+
+	if len(compiler.EnvironmentTrace()) > 0 {
+		t.Errorf("EnvironmentTrace is %v, want none", compiler.EnvironmentTrace())
+	}
+}
+
+func TestNative_EnvironmentStages_Trace(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	config := `version: "1"
+stages:
+  test:
+    steps:
+      - name: unit
+        image: alpine
+        environment:
+          FOO: bar
+`
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.WithEnvironmentTrace(true)
+
+	p, err := compiler.Parse([]byte(config))
+	if err != nil {
+		t.Errorf("Parse returned err: %v", err)
+	}
+
+	// run test
+	_, err = compiler.EnvironmentStages(p.Stages, nil)
+	if err != nil {
+		t.Errorf("EnvironmentStages returned err: %v", err)
+	}
+
+	traces := compiler.EnvironmentTrace()
+
+	if len(traces) != 1 || traces[0].Stage != "test" || traces[0].Step != "unit" {
+		t.Errorf("EnvironmentTrace is %v, want trace for unit in test", traces)
+	}
+}
@@ -7,6 +7,8 @@ package native
 import (
 	"fmt"
 
+	"github.com/go-vela/compiler/compiler"
+
 	"github.com/go-vela/types/pipeline"
 	"github.com/go-vela/types/yaml"
 )
@@ -75,6 +77,9 @@ func (c *client) TransformStages(r *pipeline.RuleData, p *yaml.Build) (*pipeline
 		Worker:   *p.Worker.ToPipeline(),
 	}
 
+	// order the stages by their needs declarations
+	pipeline.Stages = orderStages(pipeline.Stages)
+
 	// set the unique ID for the executable pipeline
 	pipeline.ID = fmt.Sprintf(pipelineID, org, name, number)
 
@@ -115,6 +120,15 @@ func (c *client) TransformStages(r *pipeline.RuleData, p *yaml.Build) (*pipeline
 		secret.Origin.ID = pattern
 	}
 
+	// capture warnings for the steps dropped from each stage
+	for _, stage := range pipeline.Stages {
+		for _, s := range p.Stages {
+			if s.Name == stage.Name {
+				c.droppedSteps(r, stage.Name, stage.Steps, s.Steps)
+			}
+		}
+	}
+
 	return pipeline.Purge(r), nil
 }
 
@@ -194,5 +208,105 @@ func (c *client) TransformSteps(r *pipeline.RuleData, p *yaml.Build) (*pipeline.
 		secret.Origin.ID = pattern
 	}
 
+	// capture warnings for the dropped steps
+	c.droppedSteps(r, "", pipeline.Steps, p.Steps)
+
 	return pipeline.Purge(r), nil
 }
+
+// droppedSteps is a helper function that captures a warning for each
+// step dropped from the pipeline because the ruleset doesn't match.
+//
+// Dropping steps is the normal behavior for rulesets, so the
+// warnings are only captured when enabled for the compiler.
+//
+// nolint: lll // ignore long line length due to parameters
+func (c *client) droppedSteps(r *pipeline.RuleData, stage string, containers pipeline.ContainerSlice, steps yaml.StepSlice) {
+	if !c.WarnDroppedSteps {
+		return
+	}
+
+	for _, container := range containers {
+		if container.Ruleset.Match(r) {
+			continue
+		}
+
+		w := &compiler.Warning{
+			Code:    compiler.WarningStepDropped,
+			Stage:   stage,
+			Step:    container.Name,
+			Message: fmt.Sprintf("step %s is dropped because the ruleset doesn't match the build", container.Name),
+		}
+
+		if len(stage) > 0 {
+			w.Message = fmt.Sprintf("step %s for stage %s is dropped because the ruleset doesn't match the build", container.Name, stage)
+		}
+
+		// locate the step with the ruleset in the yaml configuration
+		var step *yaml.Step
+
+		for _, s := range steps {
+			if s.Name == container.Name {
+				step = s
+
+				break
+			}
+		}
+
+		c.warn(w, step, "ruleset")
+	}
+}
+
+// orderStages is a helper function that sorts the stages into
+// a topological order based off their needs declarations.
+//
+// The sort is stable, so the stages retain their declared order
+// unless a stage is declared before a stage it needs. Needs for
+// stages that don't exist in the pipeline are ignored, and any
+// stages left in a cycle are appended in their declared order.
+func orderStages(s pipeline.StageSlice) pipeline.StageSlice {
+	// capture the names of the stages in the pipeline
+	names := make(map[string]bool)
+	for _, stage := range s {
+		names[stage.Name] = true
+	}
+
+	ordered := pipeline.StageSlice{}
+	placed := make(map[string]bool)
+	remaining := append(pipeline.StageSlice{}, s...)
+
+	for len(remaining) > 0 {
+		index := -1
+
+		// find the first stage with every stage it needs already placed
+		for i, stage := range remaining {
+			ready := true
+
+			for _, need := range stage.Needs {
+				if need != stage.Name && names[need] && !placed[need] {
+					ready = false
+
+					break
+				}
+			}
+
+			if ready {
+				index = i
+
+				break
+			}
+		}
+
+		// check if no stages could be placed due to a cycle
+		if index < 0 {
+			return append(ordered, remaining...)
+		}
+
+		ordered = append(ordered, remaining[index])
+		placed[remaining[index].Name] = true
+
+		remaining = append(remaining[:index], remaining[index+1:]...)
+	}
+
+	return ordered
+}
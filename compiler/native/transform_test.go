@@ -60,7 +60,7 @@ func TestNative_TransformStages(t *testing.T) {
 				Steps: yaml.StepSlice{
 					&yaml.Step{
 						Commands:    []string{"./gradlew downloadDependencies"},
-						Environment: environment(nil, nil, nil, nil),
+						Environment: environment(nil, nil, nil, nil, nil),
 						Image:       "openjdk:latest",
 						Name:        "install",
 						Pull:        "always",
@@ -73,7 +73,7 @@ func TestNative_TransformStages(t *testing.T) {
 				Steps: yaml.StepSlice{
 					&yaml.Step{
 						Commands:    []string{"./gradlew check"},
-						Environment: environment(nil, nil, nil, nil),
+						Environment: environment(nil, nil, nil, nil, nil),
 						Image:       "openjdk:latest",
 						Name:        "test",
 						Pull:        "always",
@@ -138,7 +138,7 @@ func TestNative_TransformStages(t *testing.T) {
 								ID:          "__0_install deps_install",
 								Commands:    []string{"./gradlew downloadDependencies"},
 								Directory:   "/vela/src",
-								Environment: environment(nil, nil, nil, nil),
+								Environment: environment(nil, nil, nil, nil, nil),
 								Image:       "openjdk:latest",
 								Name:        "install",
 								Number:      1,
@@ -193,7 +193,7 @@ func TestNative_TransformStages(t *testing.T) {
 								ID:          "localOrg_localRepo_1_install deps_install",
 								Commands:    []string{"./gradlew downloadDependencies"},
 								Directory:   "/vela/src",
-								Environment: environment(nil, nil, nil, nil),
+								Environment: environment(nil, nil, nil, nil, nil),
 								Image:       "openjdk:latest",
 								Name:        "install",
 								Number:      1,
@@ -254,6 +254,54 @@ func TestNative_TransformStages(t *testing.T) {
 	}
 }
 
+func TestNative_orderStages(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		stages pipeline.StageSlice
+		want   []string
+	}{
+		{ // ordered stages
+			stages: pipeline.StageSlice{
+				&pipeline.Stage{Name: "clone"},
+				&pipeline.Stage{Name: "install", Needs: []string{"clone"}},
+				&pipeline.Stage{Name: "test", Needs: []string{"install", "clone"}},
+			},
+			want: []string{"clone", "install", "test"},
+		},
+		{ // unordered stages
+			stages: pipeline.StageSlice{
+				&pipeline.Stage{Name: "publish", Needs: []string{"build", "test", "clone"}},
+				&pipeline.Stage{Name: "test", Needs: []string{"install", "clone"}},
+				&pipeline.Stage{Name: "lint", Needs: []string{"clone"}},
+				&pipeline.Stage{Name: "build", Needs: []string{"install", "clone"}},
+				&pipeline.Stage{Name: "install", Needs: []string{"clone"}},
+			},
+			want: []string{"lint", "install", "test", "build", "publish"},
+		},
+		{ // cycle stages
+			stages: pipeline.StageSlice{
+				&pipeline.Stage{Name: "a", Needs: []string{"b"}},
+				&pipeline.Stage{Name: "b", Needs: []string{"a"}},
+				&pipeline.Stage{Name: "c"},
+			},
+			want: []string{"c", "a", "b"},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got := []string{}
+
+		for _, stage := range orderStages(test.stages) {
+			got = append(got, stage.Name)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("orderStages is %v, want %v", got, test.want)
+		}
+	}
+}
+
 func TestNative_TransformSteps(t *testing.T) {
 	// setup types
 	set := flag.NewFlagSet("test", 0)
@@ -295,14 +343,14 @@ func TestNative_TransformSteps(t *testing.T) {
 		Steps: yaml.StepSlice{
 			&yaml.Step{
 				Commands:    []string{"./gradlew downloadDependencies"},
-				Environment: environment(nil, nil, nil, nil),
+				Environment: environment(nil, nil, nil, nil, nil),
 				Image:       "openjdk:latest",
 				Name:        "install deps",
 				Pull:        "always",
 			},
 			&yaml.Step{
 				Commands:    []string{"./gradlew check"},
-				Environment: environment(nil, nil, nil, nil),
+				Environment: environment(nil, nil, nil, nil, nil),
 				Image:       "openjdk:latest",
 				Name:        "test",
 				Pull:        "always",
@@ -362,7 +410,7 @@ func TestNative_TransformSteps(t *testing.T) {
 						ID:          "step___0_install deps",
 						Commands:    []string{"./gradlew downloadDependencies"},
 						Directory:   "/vela/src",
-						Environment: environment(nil, nil, nil, nil),
+						Environment: environment(nil, nil, nil, nil, nil),
 						Image:       "openjdk:latest",
 						Name:        "install deps",
 						Number:      1,
@@ -412,7 +460,7 @@ func TestNative_TransformSteps(t *testing.T) {
 						ID:          "step_localOrg_localRepo_1_install deps",
 						Commands:    []string{"./gradlew downloadDependencies"},
 						Directory:   "/vela/src",
-						Environment: environment(nil, nil, nil, nil),
+						Environment: environment(nil, nil, nil, nil, nil),
 						Image:       "openjdk:latest",
 						Name:        "install deps",
 						Number:      1,
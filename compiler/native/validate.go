@@ -6,115 +6,454 @@ package native
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/go-vela/compiler/compiler"
 
 	"github.com/go-vela/types/yaml"
+
+	yaml3 "gopkg.in/yaml.v3"
 )
 
 // Validate verifies the the yaml configuration is valid.
+//
+// When the configuration is invalid, the returned error is a
+// *compiler.ValidationError containing every problem found.
 func (c *client) Validate(p *yaml.Build) error {
+	v := new(compiler.ValidationError)
+
 	// check a version is provided
 	if len(p.Version) == 0 {
-		return fmt.Errorf("no version provided")
+		v.Add(&compiler.ValidationProblem{
+			Code:     compiler.CodeNoVersion,
+			Path:     "version",
+			Message:  "no version provided",
+			Position: c.positions.top("version"),
+		})
 	}
 
 	// check that stages or steps are provided
 	if len(p.Stages) == 0 && len(p.Steps) == 0 {
-		return fmt.Errorf("no stages or steps provided")
+		v.Add(&compiler.ValidationProblem{
+			Code:    compiler.CodeNoStagesOrSteps,
+			Message: "no stages or steps provided",
+		})
 	}
 
 	// check that stages and steps aren't provided
 	if len(p.Stages) > 0 && len(p.Steps) > 0 {
-		return fmt.Errorf("stages and steps provided")
+		v.Add(&compiler.ValidationProblem{
+			Code:     compiler.CodeStagesAndSteps,
+			Message:  "stages and steps provided",
+			Position: c.positions.top("steps"),
+		})
 	}
 
 	// validate the services block provided
-	err := validateServices(p.Services)
-	if err != nil {
-		return err
-	}
+	validateServices(v, c.positions, p.Services)
 
 	// validate the stages block provided
-	err = validateStages(p.Stages)
-	if err != nil {
-		return err
-	}
+	validateStages(v, c.positions, p.Stages)
 
 	// validate the steps block provided
-	err = validateSteps(p.Steps)
-	if err != nil {
-		return err
+	validateSteps(v, c.positions, p.Steps, "steps", "")
+
+	// validate the names are unique for the pipeline
+	c.validateNames(v, p)
+
+	return v.Err()
+}
+
+// named represents an entry from the yaml
+// configuration that requires a unique name.
+type named struct {
+	name  string
+	value interface{}
+}
+
+// validateNames is a helper function that verifies the stages,
+// steps, services and secrets in the yaml configuration have
+// unique names since the names are used to create the IDs.
+func (c *client) validateNames(v *compiler.ValidationError, p *yaml.Build) {
+	entries := []named{}
+	for _, service := range p.Services {
+		entries = append(entries, named{service.Name, service})
+	}
+
+	c.validateUnique(v, entries, "services", "service", "")
+
+	entries = []named{}
+	for _, secret := range p.Secrets {
+		entries = append(entries, named{secret.Name, secret})
+	}
+
+	c.validateUnique(v, entries, "secrets", "secret", "")
+
+	entries = []named{}
+	for _, stage := range p.Stages {
+		entries = append(entries, named{stage.Name, stage})
+	}
+
+	c.validateUnique(v, entries, "stages", "stage", "")
+
+	for _, stage := range p.Stages {
+		entries = []named{}
+		for _, step := range stage.Steps {
+			entries = append(entries, named{step.Name, step})
+		}
+
+		c.validateUnique(v, entries, fmt.Sprintf("stages.%s.steps", stage.Name), "step", stage.Name)
+	}
+
+	entries = []named{}
+	for _, step := range p.Steps {
+		entries = append(entries, named{step.Name, step})
+	}
+
+	c.validateUnique(v, entries, "steps", "step", "")
+}
+
+// validateUnique is a helper function that verifies the entries
+// from the yaml configuration have unique names, reporting the
+// template that introduced each entry with a colliding name.
+//
+// The stage is empty when the entries are not part of a stage.
+//
+// nolint: lll // ignore long line length due to parameters
+func (c *client) validateUnique(v *compiler.ValidationError, entries []named, prefix, kind, stage string) {
+	// capture the entries for each name
+	found := make(map[string][]named)
+
+	for i, entry := range entries {
+		// skip entries without a name which are reported separately
+		if len(entry.name) == 0 {
+			continue
+		}
+
+		found[entry.name] = append(found[entry.name], entry)
+
+		// only report the collision for the second entry with the name
+		if len(found[entry.name]) != 2 {
+			continue
+		}
+
+		// capture where each of the colliding entries was introduced
+		sources := []string{}
+
+		for _, e := range entries {
+			if e.name != entry.name {
+				continue
+			}
+
+			source := "the pipeline"
+			if o, ok := c.origins[e.value]; ok {
+				source = o.String()
+			}
+
+			if !contains(sources, source) {
+				sources = append(sources, source)
+			}
+		}
+
+		msg := fmt.Sprintf("%s %s", kind, entry.name)
+		if len(stage) > 0 {
+			msg = fmt.Sprintf("%s for stage %s", msg, stage)
+		}
+
+		problem := &compiler.ValidationProblem{
+			Code:     compiler.CodeDuplicateName,
+			Path:     fmt.Sprintf("%s[%d].name", prefix, i),
+			Stage:    stage,
+			Message:  fmt.Sprintf("%s is not unique: declared by %s", msg, strings.Join(sources, " and ")),
+			Position: c.positions.position(entry.value, "name"),
+		}
+
+		switch kind {
+		case "stage":
+			problem.Stage = entry.name
+		case "step":
+			problem.Step = entry.name
+		case "service":
+			problem.Service = entry.name
+		}
+
+		// locate the templated step when the entry was introduced by a template
+		if o, ok := c.origins[entry.value]; ok {
+			problem.Position = c.positions.position(o.step, "template")
+		}
+
+		v.Add(problem)
+	}
+}
+
+// contains is a helper function that checks
+// if the slice of strings contains the value.
+func contains(s []string, value string) bool {
+	for _, v := range s {
+		if v == value {
+			return true
+		}
 	}
 
-	return nil
+	return false
 }
 
 // validateServices is a helper function that verifies the
 // services block in the yaml configuration is valid.
-func validateServices(s yaml.ServiceSlice) error {
-	for _, service := range s {
+func validateServices(v *compiler.ValidationError, pos *positions, s yaml.ServiceSlice) {
+	for i, service := range s {
+		path := fmt.Sprintf("services[%d]", i)
+
 		if len(service.Name) == 0 {
-			return fmt.Errorf("no name provided for service")
+			v.Add(&compiler.ValidationProblem{
+				Code:     compiler.CodeNoName,
+				Path:     path + ".name",
+				Message:  "no name provided for service",
+				Position: pos.position(service, "name"),
+			})
 		}
 
 		if len(service.Image) == 0 {
-			return fmt.Errorf("no image provided for service %s", service.Name)
+			v.Add(&compiler.ValidationProblem{
+				Code:     compiler.CodeNoImage,
+				Path:     path + ".image",
+				Service:  service.Name,
+				Message:  fmt.Sprintf("no image provided for service %s", service.Name),
+				Position: pos.position(service, "image"),
+			})
 		}
 	}
-
-	return nil
 }
 
 // validateStages is a helper function that verifies the
 // stages block in the yaml configuration is valid.
-func validateStages(s yaml.StageSlice) error {
+func validateStages(v *compiler.ValidationError, pos *positions, s yaml.StageSlice) {
+	// capture the names of the stages for the needs declarations
+	names := make(map[string]bool)
 	for _, stage := range s {
+		names[stage.Name] = true
+	}
+
+	for i, stage := range s {
+		// stages are declared as a map in the yaml configuration
+		// so the stage name is used to identify the stage
+		path := fmt.Sprintf("stages.%s", stage.Name)
+
 		if len(stage.Name) == 0 {
-			return fmt.Errorf("no name provided for stage")
+			path = fmt.Sprintf("stages[%d]", i)
+
+			v.Add(&compiler.ValidationProblem{
+				Code:     compiler.CodeNoName,
+				Path:     path + ".name",
+				Message:  "no name provided for stage",
+				Position: pos.position(stage, "name"),
+			})
 		}
 
-		// validate that a stage is not referencing itself in needs
-		for _, need := range stage.Needs {
-			if stage.Name == need {
-				return fmt.Errorf("stage %s references itself in 'needs' declaration", stage.Name)
+		// capture the needs explicitly declared for the stage
+		declared := declaredNeeds(pos, stage)
+
+		for j, need := range stage.Needs {
+			problem := &compiler.ValidationProblem{
+				Path:     fmt.Sprintf("%s.needs[%d]", path, j),
+				Stage:    stage.Name,
+				Position: pos.position(stage, "needs"),
 			}
-		}
 
-		for _, step := range stage.Steps {
-			if len(step.Name) == 0 {
-				return fmt.Errorf("no name provided for step for stage %s", stage.Name)
+			switch {
+			// validate that a stage is not referencing itself in needs
+			case stage.Name == need:
+				problem.Code = compiler.CodeNeedsSelf
+				problem.Message = fmt.Sprintf("stage %s references itself in 'needs' declaration", need)
+			// validate that a stage is not referencing the injected stages in needs
+			//
+			// the clone and init stages are injected by the compiler and run before
+			// every other stage, so they are only referenced implicitly in needs
+			case need == cloneStageName || need == initStageName:
+				if !declared[need] {
+					continue
+				}
+
+				problem.Code = compiler.CodeNeedsInjected
+				problem.Message = fmt.Sprintf(
+					"stage %s references the injected %s stage in 'needs' declaration",
+					stage.Name, need,
+				)
+			// validate that a stage is referencing an existing stage in needs
+			case !names[need]:
+				problem.Code = compiler.CodeNeedsUnknown
+				problem.Message = fmt.Sprintf(
+					"stage %s references unknown stage %s in 'needs' declaration",
+					stage.Name, need,
+				)
+			default:
+				continue
 			}
 
-			// nolint: lll // ignore simplification here
-			if len(step.Image) == 0 && len(step.Template.Name) == 0 {
-				return fmt.Errorf("no image or template provided for step %s for stage %s", step.Name, stage.Name)
+			v.Add(problem)
+		}
+
+		validateSteps(v, pos, stage.Steps, path+".steps", stage.Name)
+	}
+
+	// validate the needs declarations don't contain a cycle
+	validateCycles(v, pos, s)
+}
+
+// validateCycles is a helper function that verifies the needs
+// declarations for the stages in the yaml configuration don't
+// reference each other in a cycle.
+func validateCycles(v *compiler.ValidationError, pos *positions, s yaml.StageSlice) {
+	stages := make(map[string]*yaml.Stage)
+	for _, stage := range s {
+		stages[stage.Name] = stage
+	}
+
+	// track the state of each stage while walking the needs
+	//
+	// visiting stages are on the current path being walked
+	// and visited stages have had all their needs walked
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[string]int)
+	path := []string{}
+
+	var walk func(stage *yaml.Stage)
+
+	walk = func(stage *yaml.Stage) {
+		state[stage.Name] = visiting
+		path = append(path, stage.Name)
+
+		for _, need := range stage.Needs {
+			next, ok := stages[need]
+
+			// skip self references and unknown stages which are reported separately
+			if !ok || need == stage.Name {
+				continue
 			}
 
-			if step.Name == "clone" || step.Name == "init" {
+			switch state[need] {
+			case visiting:
+				// capture the stages on the path that form the cycle
+				cycle := []string{}
+
+				for i := len(path) - 1; i >= 0; i-- {
+					cycle = append([]string{path[i]}, cycle...)
+
+					if path[i] == need {
+						break
+					}
+				}
+
+				cycle = append(cycle, need)
+
+				v.Add(&compiler.ValidationProblem{
+					Code:     compiler.CodeNeedsCycle,
+					Path:     fmt.Sprintf("stages.%s.needs", stage.Name),
+					Stage:    stage.Name,
+					Message:  fmt.Sprintf("stages %s form a cycle in 'needs' declaration", strings.Join(cycle, " -> ")),
+					Position: pos.position(stage, "needs"),
+				})
+			case visited:
 				continue
+			default:
+				walk(next)
 			}
+		}
 
-			// nolint: lll // ignore simplification here
-			if len(step.Commands) == 0 && len(step.Environment) == 0 &&
-				len(step.Parameters) == 0 && len(step.Secrets) == 0 &&
-				len(step.Template.Name) == 0 && !step.Detach {
-				return fmt.Errorf("no commands, environment, parameters, secrets or template provided for step %s for stage %s", step.Name, stage.Name)
+		path = path[:len(path)-1]
+		state[stage.Name] = visited
+	}
+
+	for _, stage := range s {
+		if state[stage.Name] == 0 {
+			walk(stage)
+		}
+	}
+}
+
+// declaredNeeds is a helper function that returns the needs
+// explicitly declared for the stage in the yaml configuration.
+//
+// The clone stage is implicitly added to the needs for every
+// stage when the yaml configuration is unmarshaled, so it is
+// only considered declared when it is found in the original
+// yaml configuration, or isn't the last need for the stage
+// when the original yaml configuration is unavailable.
+func declaredNeeds(pos *positions, stage *yaml.Stage) map[string]bool {
+	declared := make(map[string]bool)
+
+	n := pos.node(stage)
+
+	// check if the original yaml configuration is unavailable
+	if n == nil {
+		for i, need := range stage.Needs {
+			if need == cloneStageName && i == len(stage.Needs)-1 {
+				continue
 			}
+
+			declared[need] = true
 		}
+
+		return declared
+	}
+
+	needs := mappingValue(n, "needs")
+	if needs == nil {
+		return declared
+	}
+
+	// needs may be declared as a single value or a list of values
+	if needs.Kind == yaml3.ScalarNode {
+		declared[needs.Value] = true
+	}
+
+	for _, need := range needs.Content {
+		declared[need.Value] = true
 	}
 
-	return nil
+	return declared
 }
 
 // validateSteps is a helper function that verifies the
 // steps block in the yaml configuration is valid.
-func validateSteps(s yaml.StepSlice) error {
-	for _, step := range s {
+//
+// The stage is empty when the steps are not part of a stage.
+//
+// nolint: lll // ignore long line length due to parameters
+func validateSteps(v *compiler.ValidationError, pos *positions, s yaml.StepSlice, prefix, stage string) {
+	// suffix appended to the problem messages
+	// when the steps are part of a stage
+	suffix := ""
+	if len(stage) > 0 {
+		suffix = fmt.Sprintf(" for stage %s", stage)
+	}
+
+	for i, step := range s {
+		path := fmt.Sprintf("%s[%d]", prefix, i)
+
 		if len(step.Name) == 0 {
-			return fmt.Errorf("no name provided for step")
+			v.Add(&compiler.ValidationProblem{
+				Code:     compiler.CodeNoName,
+				Path:     path + ".name",
+				Stage:    stage,
+				Message:  "no name provided for step" + suffix,
+				Position: pos.position(step, "name"),
+			})
 		}
 
 		if len(step.Image) == 0 && len(step.Template.Name) == 0 {
-			return fmt.Errorf("no image or template provided for step %s", step.Name)
+			v.Add(&compiler.ValidationProblem{
+				Code:     compiler.CodeNoImageOrTemplate,
+				Path:     path + ".image",
+				Stage:    stage,
+				Step:     step.Name,
+				Message:  fmt.Sprintf("no image or template provided for step %s%s", step.Name, suffix),
+				Position: pos.position(step, "image"),
+			})
 		}
 
 		if step.Name == "clone" || step.Name == "init" {
@@ -125,9 +464,17 @@ func validateSteps(s yaml.StepSlice) error {
 		if len(step.Commands) == 0 && len(step.Environment) == 0 &&
 			len(step.Parameters) == 0 && len(step.Secrets) == 0 &&
 			len(step.Template.Name) == 0 && !step.Detach {
-			return fmt.Errorf("no commands, environment, parameters, secrets or template provided for step %s", step.Name)
+			// nolint: lll // ignore long line length due to error message
+			msg := fmt.Sprintf("no commands, environment, parameters, secrets or template provided for step %s%s", step.Name, suffix)
+
+			v.Add(&compiler.ValidationProblem{
+				Code:     compiler.CodeNoCommands,
+				Path:     path + ".commands",
+				Stage:    stage,
+				Step:     step.Name,
+				Message:  msg,
+				Position: pos.position(step, "commands"),
+			})
 		}
 	}
-
-	return nil
 }
@@ -5,12 +5,19 @@
 package native
 
 import (
+	"errors"
 	"flag"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/go-vela/compiler/compiler"
+
 	"github.com/go-vela/types/raw"
 	"github.com/go-vela/types/yaml"
+	"github.com/google/go-cmp/cmp"
 
+	"github.com/gin-gonic/gin"
 	"github.com/urfave/cli/v2"
 )
 
@@ -415,6 +422,169 @@ func TestNative_Validate_Stages_NeedsSelfReference(t *testing.T) {
 	}
 }
 
+func TestNative_Validate_Stages_Needs(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	// setup tests
+	tests := []struct {
+		name   string
+		stages yaml.StageSlice
+		want   []*compiler.ValidationProblem
+	}{
+		{
+			name: "implicit clone",
+			stages: yaml.StageSlice{
+				testStage("foo", "clone"),
+				testStage("bar", "foo", "clone"),
+			},
+			want: nil,
+		},
+		{
+			name: "unknown stage",
+			stages: yaml.StageSlice{
+				testStage("foo", "baz", "clone"),
+			},
+			want: []*compiler.ValidationProblem{
+				{
+					Code:    compiler.CodeNeedsUnknown,
+					Path:    "stages.foo.needs[0]",
+					Stage:   "foo",
+					Message: "stage foo references unknown stage baz in 'needs' declaration",
+				},
+			},
+		},
+		{
+			name: "injected stages",
+			stages: yaml.StageSlice{
+				testStage("foo", "init", "clone", "clone"),
+			},
+			want: []*compiler.ValidationProblem{
+				{
+					Code:    compiler.CodeNeedsInjected,
+					Path:    "stages.foo.needs[0]",
+					Stage:   "foo",
+					Message: "stage foo references the injected init stage in 'needs' declaration",
+				},
+				{
+					Code:    compiler.CodeNeedsInjected,
+					Path:    "stages.foo.needs[1]",
+					Stage:   "foo",
+					Message: "stage foo references the injected clone stage in 'needs' declaration",
+				},
+				{
+					Code:    compiler.CodeNeedsInjected,
+					Path:    "stages.foo.needs[2]",
+					Stage:   "foo",
+					Message: "stage foo references the injected clone stage in 'needs' declaration",
+				},
+			},
+		},
+		{
+			name: "cycle",
+			stages: yaml.StageSlice{
+				testStage("a", "b", "clone"),
+				testStage("b", "c", "clone"),
+				testStage("c", "a", "clone"),
+			},
+			want: []*compiler.ValidationProblem{
+				{
+					Code:    compiler.CodeNeedsCycle,
+					Path:    "stages.c.needs",
+					Stage:   "c",
+					Message: "stages a -> b -> c -> a form a cycle in 'needs' declaration",
+				},
+			},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		p := &yaml.Build{
+			Version: "1",
+			Stages:  test.stages,
+		}
+
+		var got *compiler.ValidationError
+
+		compiler, err := New(c)
+		if err != nil {
+			t.Errorf("Unable to create new compiler: %v", err)
+		}
+
+		err = compiler.Validate(p)
+
+		if test.want == nil {
+			if err != nil {
+				t.Errorf("Validate for %s returned err: %v", test.name, err)
+			}
+
+			continue
+		}
+
+		if !errors.As(err, &got) {
+			t.Errorf("Validate for %s returned err %v, want ValidationError", test.name, err)
+
+			continue
+		}
+
+		if diff := cmp.Diff(test.want, got.Problems); diff != "" {
+			t.Errorf("Validate for %s mismatch (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestNative_Validate_Stages_NeedsPosition(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	b := []byte(`version: "1"
+stages:
+  test:
+    needs: [ clone ]
+    steps:
+      - name: foo
+        image: alpine
+        commands: [ echo hello ]
+`)
+
+	want := []*compiler.ValidationProblem{
+		{
+			Code:     compiler.CodeNeedsInjected,
+			Path:     "stages.test.needs[0]",
+			Stage:    "test",
+			Message:  "stage test references the injected clone stage in 'needs' declaration",
+			Position: &compiler.Position{Line: 4, Column: 5},
+		},
+	}
+
+	var got *compiler.ValidationError
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Unable to create new compiler: %v", err)
+	}
+
+	p, err := compiler.Parse(b)
+	if err != nil {
+		t.Errorf("Parse returned err: %v", err)
+	}
+
+	err = compiler.Validate(p)
+	if !errors.As(err, &got) {
+		t.Errorf("Validate returned err %v, want ValidationError", err)
+
+		return
+	}
+
+	if diff := cmp.Diff(want, got.Problems); diff != "" {
+		t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestNative_Validate_Steps(t *testing.T) {
 	// setup types
 	set := flag.NewFlagSet("test", 0)
@@ -530,3 +700,245 @@ func TestNative_Validate_Steps_NoCommands(t *testing.T) {
 		t.Errorf("Validate should have returned err")
 	}
 }
+
+func TestNative_Validate_DuplicateNames(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	p := &yaml.Build{
+		Version: "1",
+		Services: yaml.ServiceSlice{
+			&yaml.Service{Name: "postgres", Image: "postgres:12"},
+			&yaml.Service{Name: "postgres", Image: "postgres:13"},
+		},
+		Secrets: yaml.SecretSlice{
+			&yaml.Secret{Name: "foo", Key: "org/repo/foo"},
+			&yaml.Secret{Name: "foo", Key: "org/repo/bar"},
+		},
+		Stages: yaml.StageSlice{
+			testStage("test"),
+			testStage("test"),
+		},
+	}
+
+	want := []*compiler.ValidationProblem{
+		{
+			Code:    compiler.CodeDuplicateName,
+			Path:    "services[1].name",
+			Service: "postgres",
+			Message: "service postgres is not unique: declared by the pipeline",
+		},
+		{
+			Code:    compiler.CodeDuplicateName,
+			Path:    "secrets[1].name",
+			Message: "secret foo is not unique: declared by the pipeline",
+		},
+		{
+			Code:    compiler.CodeDuplicateName,
+			Path:    "stages[1].name",
+			Stage:   "test",
+			Message: "stage test is not unique: declared by the pipeline",
+		},
+	}
+
+	var got *compiler.ValidationError
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Unable to create new compiler: %v", err)
+	}
+
+	err = compiler.Validate(p)
+	if !errors.As(err, &got) {
+		t.Errorf("Validate returned err %v, want ValidationError", err)
+
+		return
+	}
+
+	if diff := cmp.Diff(want, got.Problems); diff != "" {
+		t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNative_Validate_DuplicateNames_Template(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+
+	resp := httptest.NewRecorder()
+	_, engine := gin.CreateTestContext(resp)
+
+	// setup mock server
+	engine.GET("/api/v3/repos/foo/bar/contents/:path", func(c *gin.Context) {
+		c.Header("Content-Type", "application/json")
+		c.Status(http.StatusOK)
+		c.File("testdata/template.json")
+	})
+
+	s := httptest.NewServer(engine)
+	defer s.Close()
+
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.Bool("github-driver", true, "doc")
+	set.String("github-url", s.URL, "doc")
+	set.String("github-token", "", "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	tmpls := map[string]*yaml.Template{
+		"gradle": {
+			Name:   "gradle",
+			Source: "github.example.com/foo/bar/template.yml",
+			Type:   "github",
+		},
+	}
+
+	p := &yaml.Build{
+		Version: "1",
+		Steps: yaml.StepSlice{
+			&yaml.Step{
+				Commands: raw.StringSlice{"echo hello"},
+				Image:    "alpine",
+				Name:     "sample_test",
+			},
+			&yaml.Step{
+				Name: "sample",
+				Template: yaml.StepTemplate{
+					Name: "gradle",
+					Variables: map[string]interface{}{
+						"image":       "openjdk:latest",
+						"environment": "{ GRADLE_USER_HOME: .gradle }",
+						"pull_policy": "pull: true",
+					},
+				},
+			},
+		},
+	}
+
+	want := []*compiler.ValidationProblem{
+		{
+			Code:    compiler.CodeDuplicateName,
+			Path:    "steps[2].name",
+			Step:    "sample_test",
+			Message: "step sample_test is not unique: declared by the pipeline and template gradle for step sample",
+		},
+	}
+
+	var got *compiler.ValidationError
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Unable to create new compiler: %v", err)
+	}
+
+	p.Steps, p.Secrets, p.Services, p.Environment, err = compiler.ExpandSteps(p, tmpls)
+	if err != nil {
+		t.Errorf("ExpandSteps returned err: %v", err)
+	}
+
+	err = compiler.Validate(p)
+	if !errors.As(err, &got) {
+		t.Errorf("Validate returned err %v, want ValidationError", err)
+
+		return
+	}
+
+	if diff := cmp.Diff(want, got.Problems); diff != "" {
+		t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNative_Validate_MultipleProblems(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	p := &yaml.Build{
+		Services: yaml.ServiceSlice{
+			&yaml.Service{
+				Name: "postgres",
+			},
+		},
+		Stages: yaml.StageSlice{
+			&yaml.Stage{
+				Name:  "test",
+				Needs: raw.StringSlice{"test"},
+				Steps: yaml.StepSlice{
+					&yaml.Step{
+						Commands: raw.StringSlice{"echo hello"},
+						Image:    "alpine",
+						Name:     "foo",
+					},
+					&yaml.Step{
+						Commands: raw.StringSlice{"echo hello"},
+						Name:     "bar",
+					},
+				},
+			},
+		},
+	}
+
+	want := []*compiler.ValidationProblem{
+		{
+			Code:    compiler.CodeNoVersion,
+			Path:    "version",
+			Message: "no version provided",
+		},
+		{
+			Code:    compiler.CodeNoImage,
+			Path:    "services[0].image",
+			Service: "postgres",
+			Message: "no image provided for service postgres",
+		},
+		{
+			Code:    compiler.CodeNeedsSelf,
+			Path:    "stages.test.needs[0]",
+			Stage:   "test",
+			Message: "stage test references itself in 'needs' declaration",
+		},
+		{
+			Code:    compiler.CodeNoImageOrTemplate,
+			Path:    "stages.test.steps[1].image",
+			Stage:   "test",
+			Step:    "bar",
+			Message: "no image or template provided for step bar for stage test",
+		},
+	}
+
+	var got *compiler.ValidationError
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Unable to create new compiler: %v", err)
+	}
+
+	err = compiler.Validate(p)
+	if !errors.As(err, &got) {
+		t.Errorf("Validate returned err %v, want ValidationError", err)
+
+		return
+	}
+
+	if diff := cmp.Diff(want, got.Problems); diff != "" {
+		t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// testStage is a helper function that creates a
+// valid stage with the needs provided for testing.
+func testStage(name string, needs ...string) *yaml.Stage {
+	return &yaml.Stage{
+		Name:  name,
+		Needs: raw.StringSlice(needs),
+		Steps: yaml.StepSlice{
+			&yaml.Step{
+				Commands: raw.StringSlice{"echo hello"},
+				Image:    "alpine",
+				Name:     name,
+			},
+		},
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-vela/compiler/template"
+
+	"github.com/go-vela/types/yaml"
+
+	"github.com/spf13/afero"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// varsFile is the name of the file alongside the
+// pipeline containing the variables for the pipeline.
+const varsFile = ".vela.vars.yml"
+
+// pipelineVars is a helper function that returns the variables for a
+// templated pipeline. The variables from the vars file alongside the
+// pipeline file are merged with the variables set with WithVars,
+// where the variables set with WithVars take precedence.
+func (c *client) pipelineVars(file string) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+
+	// check if the pipeline was provided as a file
+	if len(file) > 0 {
+		a := &afero.Afero{
+			Fs: afero.NewOsFs(),
+		}
+
+		path := filepath.Join(filepath.Dir(file), varsFile)
+
+		b, err := a.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("unable to read vars file %s: %w", path, err)
+		}
+
+		err = yaml3.Unmarshal(b, &vars)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal vars file %s: %w", path, err)
+		}
+	}
+
+	for key, value := range c.vars {
+		vars[key] = value
+	}
+
+	return vars, nil
+}
+
+// strictTemplate is a helper function that returns if the variables for the
+// template are rendered in strict mode. The strict key for the template in
+// the pipeline takes precedence over the strict mode for the compiler.
+func (c *client) strictTemplate(tmpl *yaml.Template) (bool, error) {
+	ext := c.extension(tmpl)
+	if ext == nil || len(ext.Strict) == 0 {
+		return c.TemplateStrict, nil
+	}
+
+	return strconv.ParseBool(ext.Strict)
+}
+
+// templateVars is a helper function that validates the variables for the
+// templated step against the schema declared by the template and returns
+// the variables with the defaults from the schema applied.
+func templateVars(tmpl *yaml.Template, step *yaml.Step, schema template.Schema) (map[string]interface{}, error) {
+	// check if the template declares a schema
+	if schema == nil {
+		return step.Template.Variables, nil
+	}
+
+	vars, err := schema.Validate(step.Template.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("invalid variables for template %s: %w", tmpl.Name, err)
+	}
+
+	return vars, nil
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"github.com/go-vela/compiler/compiler"
+)
+
+// Warnings returns the non-fatal problems found
+// while compiling the last yaml configuration.
+func (c *client) Warnings() []*compiler.Warning {
+	return c.warnings
+}
+
+// warn is a helper function that captures the warning with the
+// location of the field for the parsed object, or the location
+// of the templated step when the object was introduced by a
+// template, unless the same warning was already captured.
+func (c *client) warn(w *compiler.Warning, v interface{}, field string) {
+	if w.Position == nil {
+		w.Position = c.positions.position(v, field)
+
+		// locate the templated step when the object was introduced by a template
+		if o, ok := c.origins[v]; ok {
+			w.Position = c.positions.position(o.step, "template")
+		}
+	}
+
+	for _, existing := range c.warnings {
+		if existing.String() == w.String() && existing.Code == w.Code {
+			return
+		}
+	}
+
+	c.warnings = append(c.warnings, w)
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/go-vela/compiler/compiler"
+
+	"github.com/go-vela/types/library"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/urfave/cli/v2"
+)
+
+func TestNative_Compile_Warnings(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	set.Bool("warn-dropped-steps", true, "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	b := new(library.Build)
+	b.SetEvent("push")
+	b.SetBranch("master")
+
+	want := []*compiler.Warning{
+		{
+			Code:     compiler.WarningEnvironmentShadowed,
+			Message:  "environment variable VELA_BUILD_BRANCH declared for the pipeline is overwritten by the platform",
+			Position: &compiler.Position{Line: 6, Column: 1},
+		},
+		{
+			Code:     compiler.WarningUnusedVariables,
+			Step:     "sample",
+			Message:  "template sample has unused variables: extra",
+			Position: &compiler.Position{Line: 30, Column: 5},
+		},
+		{
+			Code:     compiler.WarningEnvironmentShadowed,
+			Step:     "install",
+			Message:  "environment variable VELA_BUILD_EVENT declared for step install is overwritten by the platform",
+			Position: &compiler.Position{Line: 17, Column: 5},
+		},
+		{
+			Code:     compiler.WarningStepDropped,
+			Step:     "publish",
+			Message:  "step publish is dropped because the ruleset doesn't match the build",
+			Position: &compiler.Position{Line: 24, Column: 5},
+		},
+	}
+
+	// run test
+	yaml, err := ioutil.ReadFile("testdata/warnings.yml")
+	if err != nil {
+		t.Errorf("Reading yaml file return err: %v", err)
+	}
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.WithBuild(b).WithLocal(true)
+
+	_, err = compiler.Compile(yaml)
+	if err != nil {
+		t.Errorf("Compile returned err: %v", err)
+	}
+
+	if diff := cmp.Diff(want, compiler.Warnings()); diff != "" {
+		t.Errorf("Warnings mismatch (-want +got):\n%s", diff)
+	}
+
+	// compiling a pipeline resets the warnings
+	_, err = compiler.Compile([]byte("version: \"1\"\nsteps:\n  - name: test\n    image: alpine\n    commands: [ls]\n"))
+	if err != nil {
+		t.Errorf("Compile returned err: %v", err)
+	}
+
+	if len(compiler.Warnings()) > 0 {
+		t.Errorf("Warnings is %v, want none", compiler.Warnings())
+	}
+}
+
+func TestNative_Compile_Warnings_DroppedStepsDisabled(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	b := new(library.Build)
+	b.SetEvent("push")
+	b.SetBranch("master")
+
+	dropped := compiler.WarningStepDropped
+
+	// run test
+	yaml, err := ioutil.ReadFile("testdata/warnings.yml")
+	if err != nil {
+		t.Errorf("Reading yaml file return err: %v", err)
+	}
+
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.WithBuild(b).WithLocal(true)
+
+	_, err = compiler.Compile(yaml)
+	if err != nil {
+		t.Errorf("Compile returned err: %v", err)
+	}
+
+	// steps dropped by the ruleset are expected so no warning is captured by default
+	for _, w := range compiler.Warnings() {
+		if w.Code == dropped {
+			t.Errorf("Warnings contains %v, want no dropped steps", w)
+		}
+	}
+}
+
+func TestNative_Compile_Warnings_MutatedVars(t *testing.T) {
+	// setup types
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+
+	b := new(library.Build)
+	b.SetEvent("push")
+	b.SetBranch("master")
+
+	config, err := ioutil.ReadFile("testdata/mutate/pipeline.yml")
+	if err != nil {
+		t.Errorf("Reading yaml file return err: %v", err)
+	}
+
+	expected, err := ioutil.ReadFile("testdata/mutate/want.yml")
+	if err != nil {
+		t.Errorf("Reading yaml file return err: %v", err)
+	}
+
+	// run test
+	compiler, err := New(c)
+	if err != nil {
+		t.Errorf("Creating compiler returned err: %v", err)
+	}
+
+	compiler.WithBuild(b).WithLocal(true)
+
+	want, err := compiler.Duplicate().WithBuild(b).WithLocal(true).Compile(expected)
+	if err != nil {
+		t.Errorf("Compile returned err: %v", err)
+	}
+
+	// templates mutating the vars compile the same as the rendered pipeline
+	got, err := compiler.Compile(config)
+	if err != nil {
+		t.Errorf("Compile returned err: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Compile mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(compiler.Warnings()) > 0 {
+		t.Errorf("Warnings is %v, want none", compiler.Warnings())
+	}
+}
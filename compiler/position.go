@@ -0,0 +1,55 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package compiler
+
+import "fmt"
+
+// Position represents the location of a key
+// in the original yaml configuration.
+type Position struct {
+	// File is the name of the yaml configuration file.
+	File string `json:"file,omitempty"`
+	// Line is the line number starting at 1.
+	Line int `json:"line"`
+	// Column is the column number starting at 1.
+	//
+	// The column is 0 when it could not be determined.
+	Column int `json:"column,omitempty"`
+}
+
+// String returns the human-readable representation of the position.
+func (p *Position) String() string {
+	// check if the file name is unknown
+	if len(p.File) == 0 {
+		if p.Column == 0 {
+			return fmt.Sprintf("line %d", p.Line)
+		}
+
+		return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+	}
+
+	if p.Column == 0 {
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// PositionError represents an error that occurred at
+// a location in the original yaml configuration.
+type PositionError struct {
+	Position *Position
+	Err      error
+}
+
+// Error implements the error interface for the PositionError type.
+func (e *PositionError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Position, e.Err)
+}
+
+// Unwrap returns the underlying error for the PositionError type.
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCompiler_Position_String(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		position *Position
+		want     string
+	}{
+		{
+			position: &Position{File: ".vela.yml", Line: 3, Column: 5},
+			want:     ".vela.yml:3:5",
+		},
+		{
+			position: &Position{File: ".vela.yml", Line: 3},
+			want:     ".vela.yml:3",
+		},
+		{
+			position: &Position{Line: 3, Column: 5},
+			want:     "line 3, column 5",
+		},
+		{
+			position: &Position{Line: 3},
+			want:     "line 3",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got := test.position.String()
+
+		if got != test.want {
+			t.Errorf("String is %v, want %v", got, test.want)
+		}
+	}
+}
+
+func TestCompiler_PositionError(t *testing.T) {
+	// setup types
+	want := errors.New("unable to unmarshal yaml")
+
+	err := &PositionError{
+		Position: &Position{File: ".vela.yml", Line: 3, Column: 5},
+		Err:      want,
+	}
+
+	// run test
+	if err.Error() != fmt.Sprintf(".vela.yml:3:5: %v", want) {
+		t.Errorf("Error is %v, want %v", err.Error(), want)
+	}
+
+	if !errors.Is(err, want) {
+		t.Errorf("PositionError should unwrap to %v", want)
+	}
+}
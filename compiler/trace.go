@@ -0,0 +1,96 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package compiler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnvironmentSource represents where the value
+// for an environment variable was declared.
+type EnvironmentSource string
+
+const (
+	// SourceLocal defines the source for the environment
+	// variables captured from the local environment.
+	SourceLocal EnvironmentSource = "local"
+
+	// SourceGlobal defines the source for the environment
+	// variables declared by the environment of the pipeline.
+	SourceGlobal EnvironmentSource = "global"
+
+	// SourceStep defines the source for the environment
+	// variables declared by the environment of the step.
+	SourceStep EnvironmentSource = "step"
+
+	// SourcePlatform defines the source for the
+	// environment variables provided by the platform.
+	SourcePlatform EnvironmentSource = "platform"
+
+	// SourceParameter defines the source for the PARAMETER_*
+	// environment variables declared by the parameters of the step.
+	SourceParameter EnvironmentSource = "parameter"
+)
+
+// EnvironmentValue represents a value for an
+// environment variable and where it was declared.
+type EnvironmentValue struct {
+	// Source is where the value was declared.
+	Source EnvironmentSource `json:"source"`
+	// Value is the value for the environment variable.
+	Value string `json:"value"`
+}
+
+// EnvironmentVariable represents the final value for an environment
+// variable of a step and the values it overwrote in the order they
+// were declared.
+type EnvironmentVariable struct {
+	// Name is the name of the environment variable.
+	Name string `json:"name"`
+	// Source is where the final value was declared.
+	Source EnvironmentSource `json:"source"`
+	// Value is the final value for the environment variable.
+	Value string `json:"value"`
+	// Shadowed contains the values overwritten by the final value.
+	Shadowed []*EnvironmentValue `json:"shadowed,omitempty"`
+}
+
+// EnvironmentTrace represents where each environment
+// variable for a compiled step was declared.
+type EnvironmentTrace struct {
+	// Stage is the name of the stage containing the step.
+	Stage string `json:"stage,omitempty"`
+	// Step is the name of the step.
+	Step string `json:"step"`
+	// Variables contains the environment variables sorted by name.
+	Variables []*EnvironmentVariable `json:"variables"`
+}
+
+// String returns the human-readable representation of the trace.
+//
+//	step build:
+//	  VELA_BUILD_EVENT="push" (platform, shadows step="custom")
+func (t *EnvironmentTrace) String() string {
+	b := new(strings.Builder)
+
+	if len(t.Stage) > 0 {
+		fmt.Fprintf(b, "stage %s step %s:\n", t.Stage, t.Step)
+	} else {
+		fmt.Fprintf(b, "step %s:\n", t.Step)
+	}
+
+	for _, v := range t.Variables {
+		fmt.Fprintf(b, "  %s=%q (%s", v.Name, v.Value, v.Source)
+
+		for _, s := range v.Shadowed {
+			fmt.Fprintf(b, ", shadows %s=%q", s.Source, s.Value)
+		}
+
+		b.WriteString(")\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package compiler
+
+import (
+	"testing"
+)
+
+func TestCompiler_EnvironmentTrace_String(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		trace *EnvironmentTrace
+		want  string
+	}{
+		{
+			trace: &EnvironmentTrace{
+				Step: "build",
+				Variables: []*EnvironmentVariable{
+					{Name: "FOO", Source: SourceStep, Value: "bar"},
+					{
+						Name:   "VELA_BUILD_EVENT",
+						Source: SourcePlatform,
+						Value:  "push",
+						Shadowed: []*EnvironmentValue{
+							{Source: SourceGlobal, Value: "pull_request"},
+							{Source: SourceStep, Value: "custom"},
+						},
+					},
+				},
+			},
+			want: "step build:\n" +
+				"  FOO=\"bar\" (step)\n" +
+				"  VELA_BUILD_EVENT=\"push\" (platform, shadows global=\"pull_request\", shadows step=\"custom\")\n",
+		},
+		{
+			trace: &EnvironmentTrace{
+				Stage: "test",
+				Step:  "unit",
+				Variables: []*EnvironmentVariable{
+					{Name: "PARAMETER_DEBUG", Source: SourceParameter, Value: "true"},
+				},
+			},
+			want: "stage test step unit:\n  PARAMETER_DEBUG=\"true\" (parameter)\n",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got := test.trace.String()
+
+		if got != test.want {
+			t.Errorf("String is %q, want %q", got, test.want)
+		}
+	}
+}
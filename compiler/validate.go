@@ -0,0 +1,140 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package compiler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationCode represents the machine-readable
+// identifier for a problem found in a yaml configuration.
+type ValidationCode string
+
+const (
+	// CodeNoVersion defines the code when
+	// the pipeline does not provide a version.
+	CodeNoVersion ValidationCode = "no_version"
+
+	// CodeNoStagesOrSteps defines the code when
+	// the pipeline does not provide stages or steps.
+	CodeNoStagesOrSteps ValidationCode = "no_stages_or_steps"
+
+	// CodeStagesAndSteps defines the code when
+	// the pipeline provides both stages and steps.
+	CodeStagesAndSteps ValidationCode = "stages_and_steps"
+
+	// CodeNoName defines the code when a stage,
+	// step or service does not provide a name.
+	CodeNoName ValidationCode = "no_name"
+
+	// CodeNoImage defines the code when
+	// a service does not provide an image.
+	CodeNoImage ValidationCode = "no_image"
+
+	// CodeNoImageOrTemplate defines the code when
+	// a step does not provide an image or template.
+	CodeNoImageOrTemplate ValidationCode = "no_image_or_template"
+
+	// CodeNoCommands defines the code when a step does not provide
+	// commands, environment, parameters, secrets or a template.
+	CodeNoCommands ValidationCode = "no_commands"
+
+	// CodeNeedsSelf defines the code when a stage
+	// references itself in the needs declaration.
+	CodeNeedsSelf ValidationCode = "needs_self"
+
+	// CodeNeedsUnknown defines the code when a stage references
+	// a stage that does not exist in the needs declaration.
+	CodeNeedsUnknown ValidationCode = "needs_unknown"
+
+	// CodeNeedsInjected defines the code when a stage references
+	// the injected clone or init stage in the needs declaration.
+	CodeNeedsInjected ValidationCode = "needs_injected"
+
+	// CodeNeedsCycle defines the code when stages
+	// reference each other in the needs declaration.
+	CodeNeedsCycle ValidationCode = "needs_cycle"
+
+	// CodeDuplicateName defines the code when a stage,
+	// step, service or secret does not have a unique name.
+	CodeDuplicateName ValidationCode = "duplicate_name"
+)
+
+// ValidationProblem represents a single problem
+// found while validating a yaml configuration.
+type ValidationProblem struct {
+	// Code is the machine-readable identifier for the problem.
+	Code ValidationCode `json:"code"`
+	// Path is the field path for the problem in the
+	// yaml configuration, i.e. stages.test.steps[2].image.
+	Path string `json:"path"`
+	// Stage is the name of the stage containing the problem.
+	Stage string `json:"stage,omitempty"`
+	// Step is the name of the step containing the problem.
+	Step string `json:"step,omitempty"`
+	// Service is the name of the service containing the problem.
+	Service string `json:"service,omitempty"`
+	// Message is the human-readable description of the problem.
+	Message string `json:"message"`
+	// Position is the location of the problem in the original
+	// yaml configuration when it could be determined.
+	Position *Position `json:"position,omitempty"`
+}
+
+// String returns the human-readable representation of the problem.
+func (p *ValidationProblem) String() string {
+	msg := p.Message
+
+	if len(p.Path) > 0 {
+		msg = fmt.Sprintf("%s: %s", p.Path, msg)
+	}
+
+	if p.Position != nil {
+		msg = fmt.Sprintf("%s: %s", p.Position, msg)
+	}
+
+	return msg
+}
+
+// ValidationError represents every problem
+// found while validating a yaml configuration.
+type ValidationError struct {
+	Problems []*ValidationProblem `json:"problems"`
+}
+
+// Add appends a problem to the validation error.
+func (e *ValidationError) Add(p *ValidationProblem) {
+	e.Problems = append(e.Problems, p)
+}
+
+// Err returns the validation error if any
+// problems were found, otherwise it returns nil.
+func (e *ValidationError) Err() error {
+	if e == nil || len(e.Problems) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// Error implements the error interface for the ValidationError type.
+func (e *ValidationError) Error() string {
+	// check if only a single problem was found
+	if len(e.Problems) == 1 {
+		return e.Problems[0].String()
+	}
+
+	problems := []string{}
+
+	for _, p := range e.Problems {
+		problems = append(problems, p.String())
+	}
+
+	return fmt.Sprintf(
+		"%d problems found in pipeline: %s",
+		len(e.Problems), strings.Join(problems, "; "),
+	)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package compiler
+
+import (
+	"testing"
+)
+
+func TestCompiler_ValidationError_Err(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		err     *ValidationError
+		wantErr bool
+	}{
+		{
+			err:     nil,
+			wantErr: false,
+		},
+		{
+			err:     new(ValidationError),
+			wantErr: false,
+		},
+		{
+			err: &ValidationError{
+				Problems: []*ValidationProblem{
+					{Code: CodeNoVersion, Path: "version", Message: "no version provided"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		err := test.err.Err()
+
+		if (err != nil) != test.wantErr {
+			t.Errorf("Err is %v, wantErr %v", err, test.wantErr)
+		}
+	}
+}
+
+func TestCompiler_ValidationError_Error(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		problems []*ValidationProblem
+		want     string
+	}{
+		{
+			problems: []*ValidationProblem{
+				{Code: CodeNoVersion, Path: "version", Message: "no version provided"},
+			},
+			want: "version: no version provided",
+		},
+		{
+			problems: []*ValidationProblem{
+				{Code: CodeNoStagesOrSteps, Message: "no stages or steps provided"},
+			},
+			want: "no stages or steps provided",
+		},
+		{
+			problems: []*ValidationProblem{
+				{Code: CodeNoVersion, Path: "version", Message: "no version provided"},
+				{Code: CodeNoImage, Path: "services[0].image", Service: "foo", Message: "no image provided for service foo"},
+			},
+			want: "2 problems found in pipeline: version: no version provided; services[0].image: no image provided for service foo",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got := (&ValidationError{Problems: test.problems}).Error()
+
+		if got != test.want {
+			t.Errorf("Error is %v, want %v", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package compiler
+
+import "fmt"
+
+// WarningCode represents the machine-readable identifier
+// for a non-fatal problem found while compiling a pipeline.
+type WarningCode string
+
+const (
+	// WarningStepDropped defines the code when a step is dropped
+	// from the pipeline because the ruleset doesn't match the build.
+	//
+	// The warning is only captured when enabled for the compiler.
+	WarningStepDropped WarningCode = "step_dropped"
+
+	// WarningTemplateDeprecated defines the code
+	// when a step uses a deprecated template.
+	WarningTemplateDeprecated WarningCode = "template_deprecated"
+
+	// WarningEnvironmentShadowed defines the code when an
+	// environment variable declared by the pipeline is
+	// overwritten by the platform environment variables.
+	WarningEnvironmentShadowed WarningCode = "environment_shadowed"
+
+	// WarningUnusedVariables defines the code when a template
+	// never references the variables provided by a step.
+	WarningUnusedVariables WarningCode = "unused_variables"
+
+	// WarningUnknownVariable defines the code when a step
+	// references an environment variable that isn't declared.
+	WarningUnknownVariable WarningCode = "unknown_variable"
+)
+
+// Warning represents a single non-fatal problem found while compiling
+// a pipeline that doesn't prevent the pipeline from being executed.
+type Warning struct {
+	// Code is the machine-readable identifier for the warning.
+	Code WarningCode `json:"code"`
+	// Stage is the name of the stage containing the warning.
+	Stage string `json:"stage,omitempty"`
+	// Step is the name of the step containing the warning.
+	Step string `json:"step,omitempty"`
+	// Message is the human-readable description of the warning.
+	Message string `json:"message"`
+	// Position is the location of the warning in the original
+	// yaml configuration when it could be determined.
+	Position *Position `json:"position,omitempty"`
+}
+
+// String returns the human-readable representation of the warning.
+func (w *Warning) String() string {
+	if w.Position != nil {
+		return fmt.Sprintf("%s: %s", w.Position, w.Message)
+	}
+
+	return w.Message
+}
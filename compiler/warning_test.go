@@ -0,0 +1,43 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package compiler
+
+import (
+	"testing"
+)
+
+func TestCompiler_Warning_String(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		warning *Warning
+		want    string
+	}{
+		{
+			warning: &Warning{
+				Code:     WarningStepDropped,
+				Step:     "publish",
+				Message:  "step publish is dropped because the ruleset doesn't match the build",
+				Position: &Position{File: ".vela.yml", Line: 24, Column: 5},
+			},
+			want: ".vela.yml:24:5: step publish is dropped because the ruleset doesn't match the build",
+		},
+		{
+			warning: &Warning{
+				Code:    WarningTemplateDeprecated,
+				Message: "template sample is deprecated",
+			},
+			want: "template sample is deprecated",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got := test.warning.String()
+
+		if got != test.want {
+			t.Errorf("String is %v, want %v", got, test.want)
+		}
+	}
+}
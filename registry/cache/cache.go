@@ -0,0 +1,201 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/go-vela/compiler/registry"
+
+	"github.com/go-vela/types/library"
+)
+
+// shaRegex matches a reference that is a full commit SHA.
+var shaRegex = regexp.MustCompile(`^([0-9a-fA-F]{40}|[0-9a-fA-F]{64})$`)
+
+type client struct {
+	Service   registry.Service
+	TTL       time.Duration
+	Size      int
+	Directory string
+
+	// entries contains the templates captured in memory
+	// which are shared with the clients for other services
+	*entries
+
+	// now returns the current time for expiring entries
+	now func() time.Time
+}
+
+// entries represents the templates captured in memory.
+type entries struct {
+	// mutex for the entries since the cache
+	// is shared between compiles
+	mutex sync.Mutex
+	items map[string]*list.Element
+	order *list.List
+}
+
+// entry represents a template captured in the cache.
+type entry struct {
+	key     string
+	data    []byte
+	fetched time.Time
+	pinned  bool
+}
+
+// New returns a Registry implementation that caches the
+// templates captured from the provided registry service.
+//
+// Templates are cached by the host, org, repo, name and ref
+// for the source until the TTL expires, except templates
+// pinned to a full commit SHA which are cached indefinitely.
+// When the size is greater than 0, the least recently used
+// templates are evicted once the size is exceeded. When the
+// directory is provided, templates are persisted on disk.
+//
+// Templates captured with the credentials for a user are cached
+// separately for each user, so a private template is never served
+// to another user referencing the same source. Templates the service
+// captures without the credentials for the user, i.e. public
+// templates, are shared between users.
+//
+// nolint: revive // ignore returning unexported client
+func New(svc registry.Service, ttl time.Duration, size int, directory string) (*client, error) {
+	// ensure the registry service is provided
+	if svc == nil {
+		return nil, fmt.Errorf("no registry service provided for cache")
+	}
+
+	// create the client object
+	c := &client{
+		Service:   svc,
+		TTL:       ttl,
+		Size:      size,
+		Directory: directory,
+		entries: &entries{
+			items: make(map[string]*list.Element),
+			order: list.New(),
+		},
+		now: time.Now,
+	}
+
+	return c, nil
+}
+
+// WithService returns a Registry implementation that caches the
+// templates captured from the provided registry service, sharing
+// the templates captured in memory with the existing cache.
+func (c *client) WithService(svc registry.Service) registry.Service {
+	return &client{
+		Service:   svc,
+		TTL:       c.TTL,
+		Size:      c.Size,
+		Directory: c.Directory,
+		entries:   c.entries,
+		now:       c.now,
+	}
+}
+
+// key is a helper function that creates the key in the cache
+// for the user and the source. The user is omitted from the key
+// when the service captures the template without the credentials
+// for the user, so the template is shared between users.
+func (c *client) key(u *library.User, s *registry.Source) string {
+	if registry.Shared(c.Service, s) {
+		return key(nil, s)
+	}
+
+	return key(u, s)
+}
+
+// key is a helper function that creates the
+// key in the cache for the user and the source.
+func key(u *library.User, s *registry.Source) string {
+	return fmt.Sprintf("%s:%s/%s/%s/%s@%s", owner(u), s.Host, s.Org, s.Repo, s.Name, s.Ref)
+}
+
+// owner is a helper function that returns the identifier for
+// the credentials of the user in the key, i.e. the checksum of
+// the token for the user, so the token is never persisted.
+func owner(u *library.User) string {
+	if len(u.GetToken()) == 0 {
+		return "anonymous"
+	}
+
+	sum := sha256.Sum256([]byte(u.GetToken()))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// pinned is a helper function that checks if the
+// reference for the source is a full commit SHA.
+func pinned(s *registry.Source) bool {
+	return shaRegex.MatchString(s.Ref)
+}
+
+// expired is a helper function that checks if
+// the entry in the cache has expired.
+func (c *client) expired(e *entry) bool {
+	return !e.pinned && c.now().Sub(e.fetched) >= c.TTL
+}
+
+// get is a helper function that returns the
+// template from memory for the key if it exists.
+func (c *client) get(k string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.items[k]
+	if !ok {
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+
+	// remove the entry if it has expired
+	if c.expired(e) {
+		c.order.Remove(elem)
+		delete(c.items, k)
+
+		return nil, false
+	}
+
+	// mark the entry as the most recently used
+	c.order.MoveToFront(elem)
+
+	return e.data, true
+}
+
+// set is a helper function that captures
+// the template in memory for the key.
+func (c *client) set(e *entry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// replace the existing entry for the key
+	if elem, ok := c.items[e.key]; ok {
+		elem.Value = e
+		c.order.MoveToFront(elem)
+
+		return
+	}
+
+	c.items[e.key] = c.order.PushFront(e)
+
+	// evict the least recently used entries when the size is exceeded
+	for c.Size > 0 && c.order.Len() > c.Size {
+		elem := c.order.Back()
+
+		c.order.Remove(elem)
+		delete(c.items, elem.Value.(*entry).key)
+	}
+}
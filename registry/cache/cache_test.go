@@ -0,0 +1,229 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-vela/compiler/registry"
+
+	"github.com/go-vela/types/library"
+)
+
+// countingRegistry represents a template service
+// that counts the templates captured for testing.
+type countingRegistry struct {
+	count int
+	err   error
+}
+
+func (r *countingRegistry) Parse(path string) (*registry.Source, error) {
+	return &registry.Source{Name: path}, nil
+}
+
+func (r *countingRegistry) Template(u *library.User, s *registry.Source) ([]byte, error) {
+	return r.TemplateWithContext(context.Background(), u, s)
+}
+
+// nolint: lll // ignore long line length due to parameters
+func (r *countingRegistry) TemplateWithContext(ctx context.Context, u *library.User, s *registry.Source) ([]byte, error) {
+	r.count++
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return []byte(key(u, s)), nil
+}
+
+// sharedRegistry represents a template service that
+// captures the public templates without the user for testing.
+type sharedRegistry struct {
+	countingRegistry
+}
+
+func (r *sharedRegistry) Shared(s *registry.Source) bool {
+	return s.Org == "public"
+}
+
+func TestCache_New(t *testing.T) {
+	// run test
+	_, err := New(new(countingRegistry), time.Minute, 10, "")
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	_, err = New(nil, time.Minute, 10, "")
+	if err == nil {
+		t.Errorf("New should have returned err")
+	}
+}
+
+func TestCache_pinned(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		ref  string
+		want bool
+	}{
+		{ref: "", want: false},
+		{ref: "main", want: false},
+		{ref: "v1.2.0", want: false},
+		{ref: "3c6f2a1", want: false},
+		{ref: "3c6f2a1b8d8e4f5a6b7c8d9e0f1a2b3c4d5e6f7a", want: true},
+		{ref: "3C6F2A1B8D8E4F5A6B7C8D9E0F1A2B3C4D5E6F7A", want: true},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got := pinned(&registry.Source{Ref: test.ref})
+
+		if got != test.want {
+			t.Errorf("pinned for %s is %v, want %v", test.ref, got, test.want)
+		}
+	}
+}
+
+func TestCache_Template_Error(t *testing.T) {
+	// setup types
+	svc := &countingRegistry{err: errors.New("rate limited")}
+
+	c, err := New(svc, time.Minute, 0, "")
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	src := &registry.Source{Org: "foo", Repo: "bar", Name: "template.yml"}
+
+	// run test
+	for i := 0; i < 2; i++ {
+		_, err = c.Template(nil, src)
+		if err == nil {
+			t.Errorf("Template should have returned err")
+		}
+	}
+
+	// errors should never be cached
+	if svc.count != 2 {
+		t.Errorf("Template fetched %d times, want %d", svc.count, 2)
+	}
+}
+
+func TestCache_Template_Users(t *testing.T) {
+	// setup types
+	svc := new(countingRegistry)
+
+	c, err := New(svc, time.Minute, 0, "")
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	foo := new(library.User)
+	foo.SetToken("foo")
+
+	bar := new(library.User)
+	bar.SetToken("bar")
+
+	src := &registry.Source{Org: "foo", Repo: "bar", Name: "template.yml", Ref: "v1.2.0"}
+
+	// run test
+	for _, u := range []*library.User{nil, foo, foo, bar} {
+		got, err := c.Template(u, src)
+		if err != nil {
+			t.Errorf("Template returned err: %v", err)
+		}
+
+		// templates should never be served to another user
+		if string(got) != key(u, src) {
+			t.Errorf("Template is %s, want %s", got, key(u, src))
+		}
+	}
+
+	if svc.count != 3 {
+		t.Errorf("Template fetched %d times, want %d", svc.count, 3)
+	}
+}
+
+func TestCache_Template_Shared(t *testing.T) {
+	// setup types
+	svc := new(sharedRegistry)
+
+	c, err := New(svc, time.Minute, 0, "")
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	foo := new(library.User)
+	foo.SetToken("foo")
+
+	bar := new(library.User)
+	bar.SetToken("bar")
+
+	ref := "3c6f2a1b8d8e4f5a6b7c8d9e0f1a2b3c4d5e6f7a"
+
+	public := &registry.Source{Org: "public", Repo: "bar", Name: "template.yml", Ref: ref}
+	private := &registry.Source{Org: "private", Repo: "bar", Name: "template.yml", Ref: ref}
+
+	// run test
+	for _, u := range []*library.User{nil, foo, bar} {
+		_, err = c.Template(u, public)
+		if err != nil {
+			t.Errorf("Template returned err: %v", err)
+		}
+	}
+
+	// public templates should be shared between users
+	if svc.count != 1 {
+		t.Errorf("Template fetched %d times, want %d", svc.count, 1)
+	}
+
+	for _, u := range []*library.User{foo, bar} {
+		got, err := c.Template(u, private)
+		if err != nil {
+			t.Errorf("Template returned err: %v", err)
+		}
+
+		// private templates should never be served to another user
+		if string(got) != key(u, private) {
+			t.Errorf("Template is %s, want %s", got, key(u, private))
+		}
+	}
+
+	if svc.count != 3 {
+		t.Errorf("Template fetched %d times, want %d", svc.count, 3)
+	}
+}
+
+func TestCache_WithService(t *testing.T) {
+	// setup types
+	svc := new(countingRegistry)
+
+	c, err := New(svc, time.Minute, 0, "")
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	src := &registry.Source{Org: "foo", Repo: "bar", Name: "template.yml", Ref: "v1.2.0"}
+
+	_, err = c.Template(nil, src)
+	if err != nil {
+		t.Errorf("Template returned err: %v", err)
+	}
+
+	// run test
+	other := new(countingRegistry)
+
+	_, err = c.WithService(other).Template(nil, src)
+	if err != nil {
+		t.Errorf("Template returned err: %v", err)
+	}
+
+	// templates should be shared with the cache for the other service
+	if svc.count != 1 || other.count != 0 {
+		t.Errorf("Template fetched %d and %d times, want %d and %d", svc.count, other.count, 1, 0)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// path is a helper function that returns the
+// file for the key in the cache directory.
+func (c *client) path(k string) string {
+	sum := sha256.Sum256([]byte(k))
+
+	return filepath.Join(c.Directory, hex.EncodeToString(sum[:]))
+}
+
+// read is a helper function that returns the template
+// from disk for the key if it exists and hasn't expired.
+func (c *client) read(k string, pinned bool) (*entry, bool) {
+	// skip if the templates are not persisted
+	if len(c.Directory) == 0 {
+		return nil, false
+	}
+
+	path := c.path(k)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+
+	e := &entry{
+		key:     k,
+		fetched: info.ModTime(),
+		pinned:  pinned,
+	}
+
+	// skip if the template has expired
+	if c.expired(e) {
+		return nil, false
+	}
+
+	e.data, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	return e, true
+}
+
+// write is a helper function that persists
+// the template on disk for the entry.
+func (c *client) write(e *entry) error {
+	// skip if the templates are not persisted
+	if len(c.Directory) == 0 {
+		return nil
+	}
+
+	// nolint: gomnd // ignore magic number
+	err := os.MkdirAll(c.Directory, 0750)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename it so
+	// other compiles never read a partial template
+	tmp, err := ioutil.TempFile(c.Directory, ".template-")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(e.data)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	// set the modification time to when the template was fetched
+	err = os.Chtimes(tmp.Name(), e.fetched, e.fetched)
+	if err != nil {
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path(e.key))
+}
@@ -0,0 +1,12 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+// Package cache provides the ability for Vela to
+// cache the templates captured from any template
+// registry in memory and optionally on disk.
+//
+// Usage:
+//
+// 	import "github.com/go-vela/compiler/registry/cache"
+package cache
@@ -0,0 +1,74 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package cache
+
+import (
+	"context"
+
+	"github.com/go-vela/compiler/registry"
+
+	"github.com/go-vela/types/library"
+	"github.com/sirupsen/logrus"
+)
+
+// Parse creates the registry source object from a template path.
+func (c *client) Parse(path string) (*registry.Source, error) {
+	return c.Service.Parse(path)
+}
+
+// Template captures the templated pipeline configuration
+// from the cache or the registry service.
+func (c *client) Template(u *library.User, s *registry.Source) ([]byte, error) {
+	return c.TemplateWithContext(context.Background(), u, s)
+}
+
+// TemplateWithContext captures the templated pipeline configuration
+// from the cache or the registry service using the provided context.
+//
+// nolint: lll // ignore long line length due to parameters
+func (c *client) TemplateWithContext(ctx context.Context, u *library.User, s *registry.Source) ([]byte, error) {
+	k := c.key(u, s)
+
+	// capture the template from memory
+	data, ok := c.get(k)
+	if ok {
+		logrus.Tracef("Using cached template %s", k)
+
+		return data, nil
+	}
+
+	// capture the template from disk
+	e, ok := c.read(k, pinned(s))
+	if ok {
+		logrus.Tracef("Using persisted template %s", k)
+
+		c.set(e)
+
+		return e.data, nil
+	}
+
+	// capture the template from the registry service
+	data, err := registry.TemplateWithContext(ctx, c.Service, u, s)
+	if err != nil {
+		return nil, err
+	}
+
+	e = &entry{
+		key:     k,
+		data:    data,
+		fetched: c.now(),
+		pinned:  pinned(s),
+	}
+
+	c.set(e)
+
+	// persist the template on disk
+	err = c.write(e)
+	if err != nil {
+		logrus.Warnf("unable to persist template %s: %v", k, err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-vela/compiler/registry"
+)
+
+func TestCache_Template(t *testing.T) {
+	// setup types
+	sha := "3c6f2a1b8d8e4f5a6b7c8d9e0f1a2b3c4d5e6f7a"
+	now := time.Now()
+
+	// setup tests
+	tests := []struct {
+		name    string
+		size    int
+		sources []*registry.Source
+		elapsed time.Duration
+		want    int
+	}{
+		{
+			name: "same source",
+			sources: []*registry.Source{
+				{Org: "foo", Repo: "bar", Name: "template.yml", Ref: "v1.2.0"},
+				{Org: "foo", Repo: "bar", Name: "template.yml", Ref: "v1.2.0"},
+			},
+			want: 1,
+		},
+		{
+			name: "different refs",
+			sources: []*registry.Source{
+				{Org: "foo", Repo: "bar", Name: "template.yml", Ref: "v1.2.0"},
+				{Org: "foo", Repo: "bar", Name: "template.yml", Ref: "v1.3.0"},
+			},
+			want: 2,
+		},
+		{
+			name: "expired",
+			sources: []*registry.Source{
+				{Org: "foo", Repo: "bar", Name: "template.yml", Ref: "main"},
+				{Org: "foo", Repo: "bar", Name: "template.yml", Ref: "main"},
+			},
+			elapsed: time.Hour,
+			want:    2,
+		},
+		{
+			name: "pinned to sha",
+			sources: []*registry.Source{
+				{Org: "foo", Repo: "bar", Name: "template.yml", Ref: sha},
+				{Org: "foo", Repo: "bar", Name: "template.yml", Ref: sha},
+			},
+			elapsed: 24 * time.Hour,
+			want:    1,
+		},
+		{
+			name: "evicted",
+			size: 1,
+			sources: []*registry.Source{
+				{Org: "foo", Repo: "bar", Name: "a.yml"},
+				{Org: "foo", Repo: "bar", Name: "b.yml"},
+				{Org: "foo", Repo: "bar", Name: "a.yml"},
+			},
+			want: 3,
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		svc := new(countingRegistry)
+
+		c, err := New(svc, time.Minute, test.size, "")
+		if err != nil {
+			t.Errorf("New returned err: %v", err)
+		}
+
+		elapsed := time.Duration(0)
+		c.now = func() time.Time { return now.Add(elapsed) }
+
+		for _, src := range test.sources {
+			got, err := c.Template(nil, src)
+			if err != nil {
+				t.Errorf("Template for %s returned err: %v", test.name, err)
+			}
+
+			if string(got) != key(nil, src) {
+				t.Errorf("Template for %s is %s, want %s", test.name, got, key(nil, src))
+			}
+
+			elapsed += test.elapsed
+		}
+
+		if svc.count != test.want {
+			t.Errorf("Template for %s fetched %d times, want %d", test.name, svc.count, test.want)
+		}
+	}
+}
+
+func TestCache_Template_Disk(t *testing.T) {
+	// setup types
+	dir := t.TempDir()
+	src := &registry.Source{Org: "foo", Repo: "bar", Name: "template.yml", Ref: "v1.2.0"}
+
+	svc := new(countingRegistry)
+
+	// run test
+	first, err := New(svc, time.Minute, 0, dir)
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	_, err = first.Template(nil, src)
+	if err != nil {
+		t.Errorf("Template returned err: %v", err)
+	}
+
+	// the second cache should capture the template from disk
+	second, err := New(svc, time.Minute, 0, dir)
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	got, err := second.Template(nil, src)
+	if err != nil {
+		t.Errorf("Template returned err: %v", err)
+	}
+
+	if string(got) != key(nil, src) {
+		t.Errorf("Template is %s, want %s", got, key(nil, src))
+	}
+
+	if svc.count != 1 {
+		t.Errorf("Template fetched %d times, want %d", svc.count, 1)
+	}
+
+	// the third cache should ignore the expired template on disk
+	third, err := New(svc, time.Minute, 0, dir)
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	third.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	_, err = third.Template(nil, src)
+	if err != nil {
+		t.Errorf("Template returned err: %v", err)
+	}
+
+	if svc.count != 2 {
+		t.Errorf("Template fetched %d times, want %d", svc.count, 2)
+	}
+}
@@ -18,6 +18,14 @@ import (
 
 // Template captures the templated pipeline configuration from the GitHub repo.
 func (c *client) Template(u *library.User, s *registry.Source) ([]byte, error) {
+	return c.TemplateWithContext(context.Background(), u, s)
+}
+
+// TemplateWithContext captures the templated pipeline configuration
+// from the GitHub repo using the provided context for the API call.
+//
+// nolint: lll // ignore long line length due to parameters
+func (c *client) TemplateWithContext(ctx context.Context, u *library.User, s *registry.Source) ([]byte, error) {
 	// use default GitHub OAuth client we provide
 	cli := c.Github
 	if u != nil {
@@ -39,8 +47,13 @@ func (c *client) Template(u *library.User, s *registry.Source) ([]byte, error) {
 	// send API call to capture the templated pipeline configuration
 	//
 	// nolint: lll // ignore long line length due to variable names
-	data, _, resp, err := cli.Repositories.GetContents(context.Background(), s.Org, s.Repo, s.Name, opts)
+	data, _, resp, err := cli.Repositories.GetContents(ctx, s.Org, s.Repo, s.Name, opts)
 	if err != nil {
+		// return the context error if the request was canceled or timed out
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("unable to fetch template %s: %w", s, ctx.Err())
+		}
+
 		if resp != nil && resp.StatusCode != http.StatusNotFound {
 			// return different error message depending on if a branch was provided
 			if len(s.Ref) == 0 {
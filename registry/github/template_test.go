@@ -5,6 +5,8 @@
 package github
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -290,3 +292,45 @@ func TestGithub_Template_NotFound(t *testing.T) {
 		t.Errorf("Template is %v, want nil", got)
 	}
 }
+
+func TestGithub_TemplateWithContext_Canceled(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	_, engine := gin.CreateTestContext(resp)
+
+	// setup mock server
+	engine.GET("/api/v3/repos/:owner/:name/contents/:path", func(c *gin.Context) {
+		c.Header("Content-Type", "application/json")
+		c.Status(http.StatusOK)
+		c.File("testdata/template.json")
+	})
+	s := httptest.NewServer(engine)
+	defer s.Close()
+
+	// setup types
+	src := &registry.Source{
+		Org:  "github",
+		Repo: "octocat",
+		Name: "template.yml",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// run test
+	c, err := New(s.URL, "")
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	got, err := c.TemplateWithContext(ctx, nil, src)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("TemplateWithContext returned err %v, want %v", err, context.Canceled)
+	}
+
+	if got != nil {
+		t.Errorf("TemplateWithContext is %v, want nil", got)
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+// Package gitlab provides the ability for Vela to
+// integrate with GitLab or a self-managed GitLab
+// instance as a template registry.
+//
+// Usage:
+//
+// 	import "github.com/go-vela/compiler/registry/gitlab"
+package gitlab
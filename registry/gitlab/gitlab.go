@@ -0,0 +1,47 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package gitlab
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultURL = "https://gitlab.com"         // Default GitLab URL
+	defaultAPI = "https://gitlab.com/api/v4/" // Default GitLab API URL
+)
+
+type client struct {
+	HTTP  *http.Client
+	URL   string
+	API   string
+	Token string
+}
+
+// New returns a Registry implementation that integrates
+// with GitLab or a self-managed GitLab instance.
+//
+// The token may be a project or personal access
+// token with permission to read the repository.
+//
+// nolint: revive // ignore returning unexported client
+func New(address, token string) (*client, error) {
+	// create the client object
+	c := &client{
+		HTTP:  http.DefaultClient,
+		URL:   defaultURL,
+		API:   defaultAPI,
+		Token: token,
+	}
+
+	// ensure we have the URL and API set
+	if len(address) > 0 {
+		c.URL = strings.TrimSuffix(address, "/")
+		c.API = c.URL + "/api/v4/"
+	}
+
+	return c, nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package gitlab
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGitlab_New(t *testing.T) {
+	// setup router
+	s := httptest.NewServer(http.NotFoundHandler())
+	defer s.Close()
+
+	want := &client{
+		HTTP:  http.DefaultClient,
+		URL:   s.URL,
+		API:   s.URL + "/api/v4/",
+		Token: "foobar",
+	}
+
+	// run test
+	got, err := New(s.URL, "foobar")
+
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("New is %v, want %v", got, want)
+	}
+}
+
+func TestGitlab_NewURL(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		address string
+		want    client
+	}{
+		{
+			// no address provided, so use default URL and API.
+			address: "",
+			want: client{
+				URL: "https://gitlab.com",
+				API: "https://gitlab.com/api/v4/",
+			},
+		},
+		{
+			// self-managed install with /
+			address: "https://git.example.com/",
+			want: client{
+				URL: "https://git.example.com",
+				API: "https://git.example.com/api/v4/",
+			},
+		},
+		{
+			// self-managed install without /
+			address: "https://git.example.com",
+			want: client{
+				URL: "https://git.example.com",
+				API: "https://git.example.com/api/v4/",
+			},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		// run test
+		got, err := New(test.address, "foobar")
+
+		if err != nil {
+			t.Errorf("New returned err: %v", err)
+		}
+
+		if got.URL != test.want.URL {
+			t.Errorf("New URL is %v, want %v", got.URL, test.want.URL)
+		}
+		if got.API != test.want.API {
+			t.Errorf("New API is %v, want %v", got.API, test.want.API)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package gitlab
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-vela/compiler/registry"
+
+	"github.com/goware/urlx"
+)
+
+// separator is the path element GitLab uses to separate
+// the project from the rest of the path in a URL.
+const separator = "/-/"
+
+// Parse creates the registry source object from
+// a template path and default branch.
+//
+// Projects in subgroups are declared by separating the
+// project from the template path with the "/-/" element:
+//
+//	<host>/<group>/<subgroup>/<project>/-/<path>/<to>/<filename>@<reference>
+//
+// The org for the source contains the full namespace of the
+// project, i.e. <group>/<subgroup>, and the repo is the project.
+func (c *client) Parse(path string) (*registry.Source, error) {
+	// ref will hold the reference identifier,
+	// eg. <group>/<project>/<filename>@<reference>
+	ref := ""
+
+	// parse the path provided
+	//
+	// goware/urlx is used over net/url because it is more consistent for parsing
+	// the template paths we use (similar to go imports)
+	u, err := urlx.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = strings.TrimPrefix(u.Path, "/")
+
+	var parts []string
+
+	// this will handle multiple cases for the path:
+	// * <group>/<project>/<filename>
+	// * <group>/<project>/<path>/<to>/<filename>
+	// * <group>/<subgroup>/<project>/-/<path>/<to>/<filename>
+	if strings.Contains(u.Path, separator) {
+		// capture the project and filename
+		pathParts := strings.SplitN(u.Path, separator, 2)
+
+		// capture the namespace and project
+		index := strings.LastIndex(pathParts[0], "/")
+		if index > 0 {
+			parts = []string{pathParts[0][:index], pathParts[0][index+1:], pathParts[1]}
+		}
+	} else {
+		// nolint: gomnd // ignore magic number
+		parts = strings.SplitN(u.Path, "/", 3)
+	}
+
+	// ensure group, project and filename parts exist
+	// nolint: gomnd // ignore magic number
+	if len(parts) < 3 || len(parts[0]) == 0 || len(parts[1]) == 0 || len(parts[2]) == 0 {
+		// nolint: lll // ignore long line length due to error message
+		return &registry.Source{}, fmt.Errorf("invalid template source %s, must contain group/project/path_to_template", path)
+	}
+
+	// check for reference provided in filename:
+	// * <group>/<project>/<filename>@<reference>
+	// * <group>/<subgroup>/<project>/-/<path>/<to>/<filename>@<reference>
+	if strings.Contains(parts[2], "@") {
+		// capture the filename and reference
+		refParts := strings.SplitN(parts[2], "@", 2)
+		// set the filename
+		parts[2] = refParts[0]
+		// set the reference
+		ref = refParts[1]
+	}
+
+	return &registry.Source{
+		Host: u.Host,
+		Org:  parts[0],
+		Repo: parts[1],
+		Name: parts[2],
+		Ref:  ref,
+	}, nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package gitlab
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-vela/compiler/registry"
+)
+
+func TestGitlab_Parse(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		path string
+		want *registry.Source
+	}{
+		{
+			path: "gitlab.example.com/foo/bar/template.yml",
+			want: &registry.Source{
+				Host: "gitlab.example.com",
+				Org:  "foo",
+				Repo: "bar",
+				Name: "template.yml",
+			},
+		},
+		{
+			path: "gitlab.example.com/foo/bar/path/to/template.yml@v1.0.0",
+			want: &registry.Source{
+				Host: "gitlab.example.com",
+				Org:  "foo",
+				Repo: "bar",
+				Name: "path/to/template.yml",
+				Ref:  "v1.0.0",
+			},
+		},
+		{
+			path: "https://gitlab.example.com/foo/bar/baz/-/path/to/template.yml@main",
+			want: &registry.Source{
+				Host: "gitlab.example.com",
+				Org:  "foo/bar",
+				Repo: "baz",
+				Name: "path/to/template.yml",
+				Ref:  "main",
+			},
+		},
+		{
+			path: "gitlab.example.com/foo/bar/-/template.yml",
+			want: &registry.Source{
+				Host: "gitlab.example.com",
+				Org:  "foo",
+				Repo: "bar",
+				Name: "template.yml",
+			},
+		},
+	}
+
+	c, err := New("", "")
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	// run tests
+	for _, test := range tests {
+		got, err := c.Parse(test.path)
+		if err != nil {
+			t.Errorf("Parse for %s returned err: %v", test.path, err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Parse for %s is %v, want %v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestGitlab_Parse_Invalid(t *testing.T) {
+	// setup tests
+	tests := []string{
+		"gitlab.example.com/foo/template.yml",
+		"gitlab.example.com/foo/-/template.yml",
+		"gitlab.example.com/foo/bar/-/",
+		"!@#$%^&*()",
+	}
+
+	c, err := New("", "")
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	// run tests
+	for _, test := range tests {
+		_, err := c.Parse(test)
+		if err == nil {
+			t.Errorf("Parse for %s should have returned err", test)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-vela/compiler/registry"
+
+	"github.com/go-vela/types/library"
+)
+
+// Template captures the templated pipeline configuration from the GitLab repo.
+func (c *client) Template(u *library.User, s *registry.Source) ([]byte, error) {
+	return c.TemplateWithContext(context.Background(), u, s)
+}
+
+// Shared reports the template is captured without the credentials
+// for the user since the access token provided to the client is used
+// to fetch every template, so the template is the same for every user.
+func (c *client) Shared(s *registry.Source) bool {
+	return true
+}
+
+// TemplateWithContext captures the templated pipeline configuration
+// from the GitLab repo using the provided context for the API call.
+//
+// Vela users authenticate with the source provider rather than
+// GitLab, so the access token provided to the client is used
+// to fetch the template instead of a token for the user.
+// The host for the source must match the GitLab instance
+// for the client when a host is provided.
+//
+// nolint: lll // ignore long line length due to parameters
+func (c *client) TemplateWithContext(ctx context.Context, u *library.User, s *registry.Source) ([]byte, error) {
+	err := c.verifyHost(s)
+	if err != nil {
+		return nil, err
+	}
+
+	// set the reference for the request to capture the templated pipeline
+	// configuration. if no ref is set, it will pull from the default
+	// branch on the targeted project, see:
+	// https://docs.gitlab.com/ee/api/repository_files.html#get-raw-file-from-repository
+	ref := s.Ref
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+
+	// create the API path for the raw file with the
+	// project and filename encoded as GitLab expects
+	path := fmt.Sprintf(
+		"%sprojects/%s/repository/files/%s/raw?ref=%s",
+		c.API,
+		url.PathEscape(s.Org+"/"+s.Repo),
+		url.PathEscape(s.Name),
+		url.QueryEscape(ref),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// set the project or personal access token for the request
+	if len(c.Token) > 0 {
+		req.Header.Set("PRIVATE-TOKEN", c.Token)
+	}
+
+	// send API call to capture the templated pipeline configuration
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		// return the context error if the request was canceled or timed out
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("unable to fetch template %s: %w", s, ctx.Err())
+		}
+
+		return nil, fmt.Errorf("unexpected error fetching template %s: %v", s, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return ioutil.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("no Vela template found at %s", s)
+	default:
+		return nil, fmt.Errorf("unexpected error fetching template %s: %s", s, resp.Status)
+	}
+}
+
+// verifyHost is a helper function that verifies the host for
+// the source matches the GitLab instance for the client.
+func (c *client) verifyHost(s *registry.Source) error {
+	// templates without a host are pulled from the configured instance
+	if len(s.Host) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid GitLab URL %s: %w", c.URL, err)
+	}
+
+	if !strings.EqualFold(s.Host, u.Host) {
+		// nolint: lll // ignore long line length due to error message
+		return fmt.Errorf("unable to fetch template %s: host %s doesn't match the configured GitLab instance %s", s, s.Host, u.Host)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-vela/compiler/registry"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGitlab_Template(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	_, engine := gin.CreateTestContext(resp)
+
+	// route on the encoded path so the project and file stay a single segment
+	engine.UseRawPath = true
+
+	// setup tests
+	tests := []struct {
+		source  *registry.Source
+		project string
+		file    string
+		ref     string
+	}{
+		{
+			source:  &registry.Source{Org: "foo", Repo: "bar", Name: "template.yml"},
+			project: "foo/bar",
+			file:    "template.yml",
+			ref:     "HEAD",
+		},
+		{
+			source:  &registry.Source{Org: "foo/bar", Repo: "baz", Name: "path/to/template.yml", Ref: "v1.0.0"},
+			project: "foo/bar/baz",
+			file:    "path/to/template.yml",
+			ref:     "v1.0.0",
+		},
+	}
+
+	var project, file, ref, token string
+
+	// setup mock server
+	engine.GET("/api/v4/projects/:id/repository/files/:file/raw", func(c *gin.Context) {
+		project = c.Param("id")
+		file = c.Param("file")
+		ref = c.Query("ref")
+		token = c.GetHeader("PRIVATE-TOKEN")
+
+		c.Status(http.StatusOK)
+		c.File("testdata/template.yml")
+	})
+	s := httptest.NewServer(engine)
+	defer s.Close()
+
+	want, err := ioutil.ReadFile("testdata/template.yml")
+	if err != nil {
+		t.Errorf("Reading file returned err: %v", err)
+	}
+
+	// run tests
+	c, err := New(s.URL, "foobar")
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	for _, test := range tests {
+		got, err := c.Template(nil, test.source)
+		if err != nil {
+			t.Errorf("Template returned err: %v", err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Template is %v, want %v", got, want)
+		}
+
+		if project != test.project {
+			t.Errorf("Template project is %v, want %v", project, test.project)
+		}
+
+		if file != test.file {
+			t.Errorf("Template file is %v, want %v", file, test.file)
+		}
+
+		if ref != test.ref {
+			t.Errorf("Template ref is %v, want %v", ref, test.ref)
+		}
+
+		if token != "foobar" {
+			t.Errorf("Template token is %v, want %v", token, "foobar")
+		}
+	}
+}
+
+func TestGitlab_Template_NotFound(t *testing.T) {
+	// setup mock server
+	s := httptest.NewServer(http.NotFoundHandler())
+	defer s.Close()
+
+	src := &registry.Source{
+		Org:  "foo",
+		Repo: "bar",
+		Name: "template.yml",
+		Ref:  "main",
+	}
+
+	// run test
+	c, err := New(s.URL, "")
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	got, err := c.Template(nil, src)
+	if err == nil {
+		t.Errorf("Template should have returned err")
+	}
+
+	want := "no Vela template found at foo/bar/template.yml@main"
+	if err != nil && err.Error() != want {
+		t.Errorf("Template err is %v, want %v", err, want)
+	}
+
+	if got != nil {
+		t.Errorf("Template is %v, want nil", got)
+	}
+}
+
+func TestGitlab_Template_BadRequest(t *testing.T) {
+	// setup mock server
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer s.Close()
+
+	src := &registry.Source{
+		Org:  "foo",
+		Repo: "bar",
+		Name: "template.yml",
+	}
+
+	// run test
+	c, err := New(s.URL, "")
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	got, err := c.Template(nil, src)
+	if err == nil {
+		t.Errorf("Template should have returned err")
+	}
+
+	if got != nil {
+		t.Errorf("Template is %v, want nil", got)
+	}
+}
+
+func TestGitlab_TemplateWithContext_Canceled(t *testing.T) {
+	// setup mock server
+	s := httptest.NewServer(http.NotFoundHandler())
+	defer s.Close()
+
+	src := &registry.Source{
+		Org:  "foo",
+		Repo: "bar",
+		Name: "template.yml",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// run test
+	c, err := New(s.URL, "")
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	_, err = c.TemplateWithContext(ctx, nil, src)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("TemplateWithContext returned err %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestGitlab_Template_Host(t *testing.T) {
+	// setup mock server
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("version: \"1\""))
+	}))
+	defer s.Close()
+
+	// setup tests
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{host: "", wantErr: false},
+		{host: strings.TrimPrefix(s.URL, "http://"), wantErr: false},
+		{host: "gitlab.com", wantErr: true},
+	}
+
+	c, err := New(s.URL, "foobar")
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	// run tests
+	for _, test := range tests {
+		src := &registry.Source{
+			Host: test.host,
+			Org:  "foo",
+			Repo: "bar",
+			Name: "template.yml",
+		}
+
+		_, err := c.Template(nil, src)
+
+		if test.wantErr && err == nil {
+			t.Errorf("Template for host %s should have returned err", test.host)
+		}
+
+		if !test.wantErr && err != nil {
+			t.Errorf("Template for host %s returned err: %v", test.host, err)
+		}
+	}
+}
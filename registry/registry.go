@@ -4,7 +4,11 @@
 
 package registry
 
-import "github.com/go-vela/types/library"
+import (
+	"context"
+
+	"github.com/go-vela/types/library"
+)
 
 // Service represents the interface for Vela integrating
 // with the different supported template registries.
@@ -12,8 +16,61 @@ type Service interface {
 	// Parse defines a function that creates the
 	// registry source object from a template path.
 	Parse(string) (*Source, error)
-
 	// Template defines a function that captures the
 	// templated pipeline configuration from a repo.
 	Template(*library.User, *Source) ([]byte, error)
 }
+
+// ContextService represents the optional interface for
+// the template registries that support canceling the
+// request for a template with a context.
+type ContextService interface {
+	Service
+
+	// TemplateWithContext defines a function that captures
+	// the templated pipeline configuration from a repo
+	// and is canceled when the provided context is done.
+	TemplateWithContext(context.Context, *library.User, *Source) ([]byte, error)
+}
+
+// TemplateWithContext captures the templated pipeline configuration
+// from the registry service. The context is passed to the service
+// when it implements the ContextService interface, otherwise the
+// context is only checked before capturing the template.
+//
+// nolint: lll // ignore long line length due to parameters
+func TemplateWithContext(ctx context.Context, svc Service, u *library.User, s *Source) ([]byte, error) {
+	if cs, ok := svc.(ContextService); ok {
+		return cs.TemplateWithContext(ctx, u, s)
+	}
+
+	// check if the context has been canceled
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return svc.Template(u, s)
+}
+
+// SharedService represents the optional interface for the
+// template registries that capture some templates without
+// the credentials for the user, i.e. public templates, so
+// the same template is captured for every user.
+type SharedService interface {
+	Service
+
+	// Shared defines a function that reports if the template for
+	// the source is captured without the credentials for the user.
+	Shared(*Source) bool
+}
+
+// Shared reports if the registry service captures the template for
+// the source without the credentials for the user. Services that
+// don't implement the SharedService interface are never shared.
+func Shared(svc Service, s *Source) bool {
+	if ss, ok := svc.(SharedService); ok {
+		return ss.Shared(s)
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-vela/types/library"
+)
+
+// legacyService represents a registry service
+// that doesn't implement the ContextService.
+type legacyService struct {
+	calls int
+}
+
+func (s *legacyService) Parse(path string) (*Source, error) {
+	return &Source{Name: path}, nil
+}
+
+func (s *legacyService) Template(u *library.User, src *Source) ([]byte, error) {
+	s.calls++
+
+	return []byte(src.Name), nil
+}
+
+// contextService represents a registry
+// service that implements the ContextService.
+type contextService struct {
+	legacyService
+}
+
+func (s *contextService) TemplateWithContext(ctx context.Context, u *library.User, src *Source) ([]byte, error) {
+	return []byte("context:" + src.Name), nil
+}
+
+// sharedService represents a registry service
+// that implements the SharedService.
+type sharedService struct {
+	legacyService
+}
+
+func (s *sharedService) Shared(src *Source) bool {
+	return src.Name == "public.yml"
+}
+
+func TestRegistry_Shared(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		svc  Service
+		name string
+		want bool
+	}{
+		{svc: new(legacyService), name: "public.yml", want: false},
+		{svc: new(sharedService), name: "public.yml", want: true},
+		{svc: new(sharedService), name: "private.yml", want: false},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got := Shared(test.svc, &Source{Name: test.name})
+
+		if got != test.want {
+			t.Errorf("Shared for %s is %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRegistry_TemplateWithContext(t *testing.T) {
+	// setup types
+	legacy := new(legacyService)
+	src := &Source{Name: "template.yml"}
+
+	// run test
+	got, err := TemplateWithContext(context.Background(), legacy, nil, src)
+	if err != nil {
+		t.Errorf("TemplateWithContext returned err: %v", err)
+	}
+
+	if string(got) != "template.yml" {
+		t.Errorf("TemplateWithContext is %s, want %s", got, "template.yml")
+	}
+
+	got, err = TemplateWithContext(context.Background(), new(contextService), nil, src)
+	if err != nil {
+		t.Errorf("TemplateWithContext returned err: %v", err)
+	}
+
+	if string(got) != "context:template.yml" {
+		t.Errorf("TemplateWithContext is %s, want %s", got, "context:template.yml")
+	}
+
+	// the legacy service isn't called with a canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = TemplateWithContext(ctx, legacy, nil, src)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("TemplateWithContext returned err %v, want %v", err, context.Canceled)
+	}
+
+	if legacy.calls != 1 {
+		t.Errorf("Template was called %d times, want %d", legacy.calls, 1)
+	}
+}
@@ -4,6 +4,8 @@
 
 package registry
 
+import "fmt"
+
 // Source represents a registry object
 // for retrieving templates.
 type Source struct {
@@ -13,3 +15,15 @@ type Source struct {
 	Name string
 	Ref  string
 }
+
+// String returns the human-readable representation
+// of the source, i.e. org/repo/name@ref, used in the
+// errors for every registry.
+func (s *Source) String() string {
+	// check if a reference was provided
+	if len(s.Ref) == 0 {
+		return fmt.Sprintf("%s/%s/%s", s.Org, s.Repo, s.Name)
+	}
+
+	return fmt.Sprintf("%s/%s/%s@%s", s.Org, s.Repo, s.Name, s.Ref)
+}
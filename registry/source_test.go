@@ -0,0 +1,35 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package registry
+
+import (
+	"testing"
+)
+
+func TestRegistry_Source_String(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		source *Source
+		want   string
+	}{
+		{
+			source: &Source{Org: "foo", Repo: "bar", Name: "template.yml"},
+			want:   "foo/bar/template.yml",
+		},
+		{
+			source: &Source{Host: "github.com", Org: "foo", Repo: "bar", Name: "template.yml", Ref: "v1"},
+			want:   "foo/bar/template.yml@v1",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got := test.source.String()
+
+		if got != test.want {
+			t.Errorf("String is %v, want %v", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+// Package url provides the ability for Vela to
+// integrate with a plain HTTP(S) server, i.e. an
+// artifact server, as a template registry.
+//
+// Usage:
+//
+// 	import "github.com/go-vela/compiler/registry/url"
+package url
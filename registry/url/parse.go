@@ -0,0 +1,38 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package url
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-vela/compiler/registry"
+)
+
+// Parse creates the registry source object from
+// a template path.
+//
+// The path must be an absolute HTTP(S) URL for the
+// template, which is captured as the name for the
+// source since there is no org or repo to reference.
+func (c *client) Parse(path string) (*registry.Source, error) {
+	// parse the path provided
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// ensure the scheme and host exist for the URL
+	if (!strings.EqualFold(u.Scheme, "https") && !strings.EqualFold(u.Scheme, "http")) || len(u.Host) == 0 {
+		// nolint: lll // ignore long line length due to error message
+		return &registry.Source{}, fmt.Errorf("invalid template source %s, must be an http or https URL", path)
+	}
+
+	return &registry.Source{
+		Host: u.Host,
+		Name: u.String(),
+	}, nil
+}
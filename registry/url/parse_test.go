@@ -0,0 +1,75 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package url
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-vela/compiler/registry"
+)
+
+func TestURL_Parse(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		path string
+		want *registry.Source
+	}{
+		{
+			path: "https://artifacts.example.com/templates/gradle.yml",
+			want: &registry.Source{
+				Host: "artifacts.example.com",
+				Name: "https://artifacts.example.com/templates/gradle.yml",
+			},
+		},
+		{
+			path: "http://localhost:8080/gradle.yml?version=1",
+			want: &registry.Source{
+				Host: "localhost:8080",
+				Name: "http://localhost:8080/gradle.yml?version=1",
+			},
+		},
+	}
+
+	c, err := New("", nil)
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	// run tests
+	for _, test := range tests {
+		got, err := c.Parse(test.path)
+		if err != nil {
+			t.Errorf("Parse for %s returned err: %v", test.path, err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Parse for %s is %v, want %v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestURL_Parse_Invalid(t *testing.T) {
+	// setup tests
+	tests := []string{
+		"artifacts.example.com/templates/gradle.yml",
+		"ftp://artifacts.example.com/gradle.yml",
+		"https:///gradle.yml",
+		"%zz",
+	}
+
+	c, err := New("", nil)
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	// run tests
+	for _, test := range tests {
+		_, err := c.Parse(test)
+		if err == nil {
+			t.Errorf("Parse for %s should have returned err", test)
+		}
+	}
+}
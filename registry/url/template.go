@@ -0,0 +1,93 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package url
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-vela/compiler/registry"
+
+	"github.com/go-vela/types/library"
+)
+
+// Template captures the templated pipeline configuration from the URL.
+func (c *client) Template(u *library.User, s *registry.Source) ([]byte, error) {
+	return c.TemplateWithContext(context.Background(), u, s)
+}
+
+// Shared reports the template is captured without the credentials
+// for the user since the token provided to the client is used to
+// fetch every template, so the template is the same for every user.
+func (c *client) Shared(s *registry.Source) bool {
+	return true
+}
+
+// TemplateWithContext captures the templated pipeline configuration
+// from the URL using the provided context for the request.
+//
+// Vela users authenticate with the source provider rather than
+// the server hosting the template, so the token provided to the
+// client is used to fetch the template instead of the user. The
+// token is only sent to the hosts trusted by the client.
+//
+// nolint: lll // ignore long line length due to parameters
+func (c *client) TemplateWithContext(ctx context.Context, u *library.User, s *registry.Source) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// use the public client for the hosts that aren't trusted
+	httpClient := c.Public
+
+	switch {
+	case c.allowed(req.URL.Host, req.URL.Hostname()) &&
+		(strings.EqualFold(req.URL.Scheme, "https") || strings.EqualFold(req.URL.Scheme, "http")):
+		httpClient = c.HTTP
+
+		// set the bearer token for the request
+		if len(c.Token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+	case !strings.EqualFold(req.URL.Scheme, "https"):
+		return nil, fmt.Errorf("invalid template source %s, must be an https URL", s.Name)
+	}
+
+	// send request to capture the templated pipeline configuration
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// return the context error if the request was canceled or timed out
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("unable to fetch template %s: %w", s.Name, ctx.Err())
+		}
+
+		return nil, fmt.Errorf("unexpected error fetching template %s: %v", s.Name, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// limit the size of the template read from the response
+		b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxTemplateSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read template %s: %w", s.Name, err)
+		}
+
+		if len(b) > maxTemplateSize {
+			return nil, fmt.Errorf("template %s exceeds the maximum size of %d bytes", s.Name, maxTemplateSize)
+		}
+
+		return b, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("no Vela template found at %s", s.Name)
+	default:
+		return nil, fmt.Errorf("unexpected error fetching template %s: %s", s.Name, resp.Status)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package url
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-vela/compiler/registry"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestURL_Template(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	_, engine := gin.CreateTestContext(resp)
+
+	var token string
+
+	// setup mock server
+	engine.GET("/templates/gradle.yml", func(c *gin.Context) {
+		token = c.GetHeader("Authorization")
+
+		c.Status(http.StatusOK)
+		c.File("testdata/template.yml")
+	})
+	s := httptest.NewServer(engine)
+	defer s.Close()
+
+	want, err := ioutil.ReadFile("testdata/template.yml")
+	if err != nil {
+		t.Errorf("Reading file returned err: %v", err)
+	}
+
+	// run test
+	c, err := New("foobar", []string{"127.0.0.1"})
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	src, err := c.Parse(s.URL + "/templates/gradle.yml")
+	if err != nil {
+		t.Errorf("Parse returned err: %v", err)
+	}
+
+	got, err := c.Template(nil, src)
+	if err != nil {
+		t.Errorf("Template returned err: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Template is %v, want %v", got, want)
+	}
+
+	if token != "Bearer foobar" {
+		t.Errorf("Template token is %v, want %v", token, "Bearer foobar")
+	}
+}
+
+func TestURL_Template_NotFound(t *testing.T) {
+	// setup mock server
+	s := httptest.NewServer(http.NotFoundHandler())
+	defer s.Close()
+
+	src := &registry.Source{
+		Name: s.URL + "/templates/gradle.yml",
+	}
+
+	// run test
+	c, err := New("", []string{"127.0.0.1"})
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	got, err := c.Template(nil, src)
+	if err == nil {
+		t.Errorf("Template should have returned err")
+	}
+
+	if got != nil {
+		t.Errorf("Template is %v, want nil", got)
+	}
+}
+
+func TestURL_Template_BadRequest(t *testing.T) {
+	// setup mock server
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer s.Close()
+
+	src := &registry.Source{
+		Name: s.URL + "/templates/gradle.yml",
+	}
+
+	// run test
+	c, err := New("", []string{"127.0.0.1"})
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	got, err := c.Template(nil, src)
+	if err == nil {
+		t.Errorf("Template should have returned err")
+	}
+
+	if got != nil {
+		t.Errorf("Template is %v, want nil", got)
+	}
+}
+
+func TestURL_TemplateWithContext_Canceled(t *testing.T) {
+	// setup mock server
+	s := httptest.NewServer(http.NotFoundHandler())
+	defer s.Close()
+
+	src := &registry.Source{
+		Name: s.URL + "/templates/gradle.yml",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// run test
+	c, err := New("", []string{"127.0.0.1"})
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	_, err = c.TemplateWithContext(ctx, nil, src)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("TemplateWithContext returned err %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestURL_Template_Untrusted(t *testing.T) {
+	// setup context
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	_, engine := gin.CreateTestContext(resp)
+
+	var token string
+
+	// setup mock server
+	engine.GET("/templates/gradle.yml", func(c *gin.Context) {
+		token = c.GetHeader("Authorization")
+
+		c.Status(http.StatusOK)
+		c.File("testdata/template.yml")
+	})
+	s := httptest.NewTLSServer(engine)
+	defer s.Close()
+
+	src := &registry.Source{
+		Name: s.URL + "/templates/gradle.yml",
+	}
+
+	c, err := New("foobar", []string{"templates.example.com"})
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	// run test
+	_, err = c.Template(nil, src)
+	if err == nil || !strings.Contains(err.Error(), "internal address") {
+		t.Errorf("Template returned err %v, want internal address error", err)
+	}
+
+	_, err = c.Template(nil, &registry.Source{Name: strings.Replace(src.Name, "https", "http", 1)})
+	if err == nil || !strings.Contains(err.Error(), "must be an https URL") {
+		t.Errorf("Template returned err %v, want https error", err)
+	}
+
+	// trust the certificate for the mock server without trusting the host
+	c.Public = s.Client()
+
+	_, err = c.Template(nil, src)
+	if err != nil {
+		t.Errorf("Template returned err: %v", err)
+	}
+
+	if len(token) > 0 {
+		t.Errorf("Template token is %v, want empty", token)
+	}
+}
+
+func TestURL_Template_TooLarge(t *testing.T) {
+	// setup mock server
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bytes.Repeat([]byte("a"), maxTemplateSize+1))
+	}))
+	defer s.Close()
+
+	src := &registry.Source{
+		Name: s.URL + "/templates/gradle.yml",
+	}
+
+	// run test
+	c, err := New("", []string{"127.0.0.1"})
+	if err != nil {
+		t.Errorf("Creating client returned err: %v", err)
+	}
+
+	got, err := c.Template(nil, src)
+	if err == nil {
+		t.Errorf("Template should have returned err")
+	}
+
+	if got != nil {
+		t.Errorf("Template is %v, want nil", got)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package url
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxTemplateSize is the maximum size in bytes
+// for a template captured from a URL.
+const maxTemplateSize = 1 << 20
+
+type client struct {
+	HTTP   *http.Client
+	Public *http.Client
+	Token  string
+	Hosts  []string
+}
+
+// New returns a Registry implementation that integrates
+// with a plain HTTP(S) server hosting templates.
+//
+// The hosts are the servers trusted to receive the token,
+// which is sent as a bearer token in the Authorization header
+// for every request to those hosts. Templates from any other
+// host must use https and can't be fetched from loopback,
+// private or link-local addresses.
+//
+// nolint: revive // ignore returning unexported client
+func New(token string, hosts []string) (*client, error) {
+	// create the client object
+	c := &client{
+		HTTP:   http.DefaultClient,
+		Public: publicClient,
+		Token:  token,
+		Hosts:  hosts,
+	}
+
+	return c, nil
+}
+
+// allowed returns true when the host for the
+// address is one of the hosts for the client.
+func (c *client) allowed(host, hostname string) bool {
+	for _, h := range c.Hosts {
+		if strings.EqualFold(h, host) || strings.EqualFold(h, hostname) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// publicClient is the HTTP client for fetching templates
+// from the hosts that aren't trusted by the client.
+var publicClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:           dialPublic,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	},
+	CheckRedirect: checkRedirect,
+}
+
+// dialer is the dialer for the connections of the public client.
+var dialer = &net.Dialer{
+	Timeout:   30 * time.Second,
+	KeepAlive: 30 * time.Second,
+}
+
+// internalNetworks contains the private and shared
+// address ranges that templates can't be fetched from.
+var internalNetworks = []*net.IPNet{
+	cidr("0.0.0.0/8"),
+	cidr("10.0.0.0/8"),
+	cidr("100.64.0.0/10"),
+	cidr("172.16.0.0/12"),
+	cidr("192.168.0.0/16"),
+	cidr("fc00::/7"),
+}
+
+// cidr is a helper function that parses the address range.
+func cidr(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
+
+// internal is a helper function that returns true when the
+// address is a loopback, private or link-local address.
+func internal(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+
+	for _, n := range internalNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dialPublic is a helper function that resolves the address and
+// creates the connection for the public client, rejecting the
+// hosts that resolve to an internal address. The resolved address
+// is dialed directly so the host can't be resolved again to a
+// different address after it is verified.
+func dialPublic(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for template host %s", host)
+	}
+
+	for _, ip := range ips {
+		if internal(ip.IP) {
+			return nil, fmt.Errorf("template host %s resolves to internal address %s", host, ip.IP)
+		}
+	}
+
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+}
+
+// checkRedirect is a helper function that
+// only follows redirects to https URLs.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after %d redirects", len(via))
+	}
+
+	if !strings.EqualFold(req.URL.Scheme, "https") {
+		return fmt.Errorf("redirect to %s is not allowed, must be an https URL", req.URL)
+	}
+
+	return nil
+}
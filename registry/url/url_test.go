@@ -0,0 +1,63 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package url
+
+import (
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestURL_New(t *testing.T) {
+	// setup types
+	want := &client{
+		HTTP:   http.DefaultClient,
+		Public: publicClient,
+		Token:  "foobar",
+		Hosts:  []string{"templates.example.com"},
+	}
+
+	// run test
+	got, err := New("foobar", []string{"templates.example.com"})
+
+	if err != nil {
+		t.Errorf("New returned err: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("New is %v, want %v", got, want)
+	}
+}
+
+func TestURL_internal(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "127.0.0.1", want: true},
+		{ip: "10.1.2.3", want: true},
+		{ip: "172.16.0.1", want: true},
+		{ip: "192.168.1.1", want: true},
+		{ip: "169.254.169.254", want: true},
+		{ip: "100.64.0.1", want: true},
+		{ip: "0.0.0.0", want: true},
+		{ip: "::1", want: true},
+		{ip: "fd00::1", want: true},
+		{ip: "fe80::1", want: true},
+		{ip: "8.8.8.8", want: false},
+		{ip: "2001:4860:4860::8888", want: false},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got := internal(net.ParseIP(test.ip))
+
+		if got != test.want {
+			t.Errorf("internal for %s is %v, want %v", test.ip, got, test.want)
+		}
+	}
+}
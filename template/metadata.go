@@ -0,0 +1,69 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Metadata represents the information declared by
+// a template about the lifecycle of the template.
+type Metadata struct {
+	Version            string `yaml:"version,omitempty"`
+	Deprecated         bool   `yaml:"deprecated,omitempty"`
+	Replacement        string `yaml:"replacement,omitempty"`
+	MinCompilerVersion string `yaml:"min_compiler_version,omitempty"`
+}
+
+// Deprecation returns the warning for the template
+// or an empty string if the template isn't deprecated.
+func (m *Metadata) Deprecation(name string) string {
+	if m == nil || !m.Deprecated {
+		return ""
+	}
+
+	warning := fmt.Sprintf("template %s", name)
+
+	if len(m.Version) > 0 {
+		warning += fmt.Sprintf(" (version %s)", m.Version)
+	}
+
+	warning += " is deprecated"
+
+	if len(m.Replacement) > 0 {
+		warning += fmt.Sprintf(", use %s instead", m.Replacement)
+	}
+
+	return warning
+}
+
+// Compatible verifies the compiler version satisfies the minimum
+// compiler version declared by the template. The check is skipped
+// when the compiler version is unknown or isn't a semantic version,
+// i.e. a development build of the compiler.
+func (m *Metadata) Compatible(name, version string) error {
+	if m == nil || len(m.MinCompilerVersion) == 0 {
+		return nil
+	}
+
+	minimum, err := semver.NewVersion(m.MinCompilerVersion)
+	if err != nil {
+		return fmt.Errorf("template %s has invalid min_compiler_version %s: %w", name, m.MinCompilerVersion, err)
+	}
+
+	current, err := semver.NewVersion(strings.TrimSpace(version))
+	if err != nil {
+		return nil
+	}
+
+	if current.LessThan(minimum) {
+		return fmt.Errorf("template %s requires compiler version %s or later (current version is %s)", name, minimum, current)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplate_Metadata_Deprecation(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		metadata *Metadata
+		want     string
+	}{
+		{
+			metadata: nil,
+			want:     "",
+		},
+		{
+			metadata: &Metadata{Version: "1.0.0"},
+			want:     "",
+		},
+		{
+			metadata: &Metadata{Deprecated: true},
+			want:     "template sample is deprecated",
+		},
+		{
+			metadata: &Metadata{Version: "1.0.0", Deprecated: true, Replacement: "sample@v2"},
+			want:     "template sample (version 1.0.0) is deprecated, use sample@v2 instead",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got := test.metadata.Deprecation("sample")
+
+		if got != test.want {
+			t.Errorf("Deprecation is %s, want %s", got, test.want)
+		}
+	}
+}
+
+func TestTemplate_Metadata_Compatible(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		metadata *Metadata
+		version  string
+		wantErr  string
+	}{
+		{
+			metadata: nil,
+			version:  "v0.10.0",
+		},
+		{
+			metadata: &Metadata{},
+			version:  "v0.10.0",
+		},
+		{
+			metadata: &Metadata{MinCompilerVersion: "0.10.0"},
+			version:  "v0.10.0",
+		},
+		{
+			metadata: &Metadata{MinCompilerVersion: "0.9"},
+			version:  "v0.10.1",
+		},
+		{
+			metadata: &Metadata{MinCompilerVersion: "0.11.0"},
+			version:  "",
+		},
+		{
+			metadata: &Metadata{MinCompilerVersion: "0.11.0"},
+			version:  "dev",
+		},
+		{
+			metadata: &Metadata{MinCompilerVersion: "0.11.0"},
+			version:  "v0.10.0",
+			wantErr:  "template sample requires compiler version 0.11.0 or later (current version is 0.10.0)",
+		},
+		{
+			metadata: &Metadata{MinCompilerVersion: "latest"},
+			version:  "v0.10.0",
+			wantErr:  "template sample has invalid min_compiler_version latest",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		err := test.metadata.Compatible("sample", test.version)
+
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("Compatible returned err %v, want %s", err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Compatible returned err: %v", err)
+		}
+	}
+}
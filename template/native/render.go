@@ -2,11 +2,13 @@ package native
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"text/template"
 
+	vela "github.com/go-vela/compiler/template"
 	"github.com/go-vela/types/raw"
-
 	types "github.com/go-vela/types/yaml"
 
 	"github.com/Masterminds/sprig/v3"
@@ -15,8 +17,32 @@ import (
 )
 
 // RenderStep combines the template with the step in the yaml pipeline.
+//
 // nolint: lll // ignore long line length due to return args
 func RenderStep(tmpl string, s *types.Step) (types.StepSlice, types.SecretSlice, types.ServiceSlice, raw.StringSliceMap, error) {
+	return RenderStepWithContext(context.Background(), tmpl, s)
+}
+
+// RenderStepWithContext combines the template with the step in the yaml
+// pipeline and stops rendering when the provided context is done.
+//
+// nolint: lll // ignore long line length due to return args
+func RenderStepWithContext(ctx context.Context, tmpl string, s *types.Step) (types.StepSlice, types.SecretSlice, types.ServiceSlice, raw.StringSliceMap, error) {
+	result, err := RenderStepWithOptions(ctx, tmpl, s, nil)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	return result.Build.Steps, result.Build.Secrets, result.Build.Services, result.Build.Environment, nil
+}
+
+// RenderStepWithOptions combines the template with the step in the yaml
+// pipeline using the provided options and stops rendering when the
+// provided context is done. The result contains the complete pipeline
+// produced by the template, i.e. including the templates it declares.
+//
+// nolint: funlen,gocyclo,lll // ignore function length due to comments
+func RenderStepWithOptions(ctx context.Context, tmpl string, s *types.Step, opts *Options) (*vela.Result, error) {
 	buffer := new(bytes.Buffer)
 	config := new(types.Build)
 
@@ -32,27 +58,56 @@ func RenderStep(tmpl string, s *types.Step) (types.StepSlice, types.SecretSlice,
 	delete(sf, "env")
 	delete(sf, "expandenv")
 
+	// remove the front matter describing the template
+	fm, body, err := parseFrontMatter(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse template %s: %v", s.Template.Name, err)
+	}
+
+	// capture the variables for the template from the declared metadata and schema
+	vars, err := opts.prepare(fm, s.Template.Variables)
+	if err != nil {
+		return nil, err
+	}
+
 	// parse the template with Masterminds/sprig functions
 	//
 	// https://pkg.go.dev/github.com/Masterminds/sprig?tab=doc#TxtFuncMap
-	t, err := template.New(s.Name).Funcs(sf).Funcs(templateFuncMap).Parse(tmpl)
+	t, err := template.New(s.Name).Funcs(sf).Funcs(templateFuncMap).Parse(body)
 	if err != nil {
-		// nolint: lll // ignore long line length due to return arguments
-		return types.StepSlice{}, types.SecretSlice{}, types.ServiceSlice{}, raw.StringSliceMap{}, fmt.Errorf("unable to parse template %s: %v", s.Template.Name, err)
+		return nil, fmt.Errorf("unable to parse template %s: %v", s.Template.Name, err)
+	}
+
+	// check if the template is rendered in strict mode
+	if opts != nil && opts.Strict {
+		t.Option("missingkey=error")
+	}
+
+	// check the template references every variable provided by the step
+	//
+	// the defaults from the schema aren't provided by the step
+	// so they're never reported when the template ignores them
+	if opts != nil && (opts.Strict || opts.Warn != nil) {
+		err = unusedVars(t, s.Template.Name, s.Template.Variables)
+		if err != nil {
+			if opts.Strict {
+				return nil, err
+			}
+
+			opts.Warn(err.Error())
+		}
 	}
 
 	// apply the variables to the parsed template
-	err = t.Execute(buffer, s.Template.Variables)
+	err = t.Execute(&contextWriter{ctx: ctx, w: buffer}, vars)
 	if err != nil {
-		// nolint: lll // ignore long line length due to return arguments
-		return types.StepSlice{}, types.SecretSlice{}, types.ServiceSlice{}, raw.StringSliceMap{}, fmt.Errorf("unable to execute template %s: %v", s.Template.Name, err)
+		return nil, fmt.Errorf("unable to execute template %s: %v", s.Template.Name, err)
 	}
 
 	// unmarshal the template to the pipeline
 	err = yaml.Unmarshal(buffer.Bytes(), config)
 	if err != nil {
-		// nolint: lll // ignore long line length due to return args
-		return types.StepSlice{}, types.SecretSlice{}, types.ServiceSlice{}, raw.StringSliceMap{}, fmt.Errorf("unable to unmarshal yaml: %v", err)
+		return nil, fmt.Errorf("unable to unmarshal yaml: %v", err)
 	}
 
 	// ensure all templated steps have template prefix
@@ -60,11 +115,30 @@ func RenderStep(tmpl string, s *types.Step) (types.StepSlice, types.SecretSlice,
 		config.Steps[index].Name = fmt.Sprintf("%s_%s", s.Name, newStep.Name)
 	}
 
-	return config.Steps, config.Secrets, config.Services, config.Environment, nil
+	return &vela.Result{Build: config, Raw: buffer.Bytes()}, nil
 }
 
 // RenderBuild renders the templated build.
 func RenderBuild(b string, envs map[string]string) (*types.Build, error) {
+	return RenderBuildWithContext(context.Background(), b, envs)
+}
+
+// RenderBuildWithContext renders the templated build and
+// stops rendering when the provided context is done.
+func RenderBuildWithContext(ctx context.Context, b string, envs map[string]string) (*types.Build, error) {
+	result, err := RenderBuildWithVars(ctx, b, envs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Build, nil
+}
+
+// RenderBuildWithVars renders the templated build with the provided pipeline
+// variables and stops rendering when the provided context is done.
+//
+// nolint: lll // ignore long line length due to parameters
+func RenderBuildWithVars(ctx context.Context, b string, envs map[string]string, vars map[string]interface{}) (*vela.Result, error) {
 	buffer := new(bytes.Buffer)
 	config := new(types.Build)
 
@@ -88,8 +162,8 @@ func RenderBuild(b string, envs map[string]string) (*types.Build, error) {
 		return nil, err
 	}
 
-	// execute the template
-	err = t.Execute(buffer, "")
+	// apply the pipeline variables to the parsed template
+	err = t.Execute(&contextWriter{ctx: ctx, w: buffer}, vars)
 	if err != nil {
 		return nil, fmt.Errorf("unable to execute template: %w", err)
 	}
@@ -100,5 +174,23 @@ func RenderBuild(b string, envs map[string]string) (*types.Build, error) {
 		return nil, fmt.Errorf("unable to unmarshal yaml: %w", err)
 	}
 
-	return config, nil
+	return &vela.Result{Build: config, Raw: buffer.Bytes()}, nil
+}
+
+// contextWriter is a helper type that aborts a template
+// execution by failing every write once the context is done.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+// Write writes the bytes to the underlying writer
+// unless the context has been canceled.
+func (c *contextWriter) Write(p []byte) (int, error) {
+	// check if the context has been canceled
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return c.w.Write(p)
 }
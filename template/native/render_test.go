@@ -5,6 +5,8 @@
 package native
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"testing"
 
@@ -144,3 +146,84 @@ func TestNative_RenderBuild(t *testing.T) {
 		})
 	}
 }
+
+func TestNative_RenderBuildWithVars(t *testing.T) {
+	// setup types
+	sFile, err := ioutil.ReadFile("testdata/build/with_vars/build.yml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	wFile, err := ioutil.ReadFile("testdata/build/with_vars/want.yml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	want := &yaml.Build{}
+
+	err = goyaml.Unmarshal(wFile, want)
+	if err != nil {
+		t.Error(err)
+	}
+
+	vars := map[string]interface{}{
+		"image": "golang:latest",
+		"steps": []interface{}{"foo", "bar"},
+	}
+
+	// run test
+	got, err := RenderBuildWithVars(context.Background(), string(sFile), map[string]string{}, vars)
+	if err != nil {
+		t.Errorf("RenderBuildWithVars returned err: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got.Build); diff != "" {
+		t.Errorf("RenderBuildWithVars mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNative_RenderStepWithContext_Canceled(t *testing.T) {
+	// setup types
+	sFile, err := ioutil.ReadFile("testdata/step/basic/step.yml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	b := &yaml.Build{}
+
+	err = goyaml.Unmarshal(sFile, b)
+	if err != nil {
+		t.Error(err)
+	}
+
+	tmpl, err := ioutil.ReadFile("testdata/step/basic/tmpl.yml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// run test
+	_, _, _, _, err = RenderStepWithContext(ctx, string(tmpl), b.Steps[0])
+	if err == nil {
+		t.Errorf("RenderStepWithContext should have returned err")
+	}
+}
+
+func TestNative_RenderBuildWithContext_Canceled(t *testing.T) {
+	// setup types
+	sFile, err := ioutil.ReadFile("testdata/build/basic/build.yml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// run test
+	_, err = RenderBuildWithContext(ctx, string(sFile), map[string]string{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("RenderBuildWithContext returned err %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-vela/compiler/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterDelimiter is the line that starts
+// and ends the front matter of a template.
+const frontMatterDelimiter = "---"
+
+// frontMatter represents the block at the top of a template,
+// delimited by "---" lines, that describes the template.
+//
+//	---
+//	metadata:
+//	  version: 1.2.0
+//	  deprecated: true
+//	  replacement: github.com/octocat/hello-world/.vela/build.yml@v2
+//	variables:
+//	  image:
+//	    type: string
+//	    required: true
+//	---
+type frontMatter struct {
+	Metadata  *template.Metadata `yaml:"metadata"`
+	Variables template.Schema    `yaml:"variables"`
+}
+
+// frontMatterKeys contains the keys supported in the front matter
+// to differentiate it from a template starting with a yaml document.
+//
+// nolint: gochecknoglobals // keys are only read
+var frontMatterKeys = map[string]bool{
+	"metadata":  true,
+	"variables": true,
+}
+
+// Schema returns the variables declared in the front matter
+// of the template or nil if the template has no front matter.
+func Schema(tmpl string) (template.Schema, error) {
+	fm, _, err := parseFrontMatter(tmpl)
+	if err != nil || fm == nil {
+		return nil, err
+	}
+
+	return fm.Variables, nil
+}
+
+// Metadata returns the metadata declared in the front matter of
+// the template or nil if the template doesn't declare metadata.
+func Metadata(tmpl string) (*template.Metadata, error) {
+	fm, _, err := parseFrontMatter(tmpl)
+	if err != nil || fm == nil {
+		return nil, err
+	}
+
+	return fm.Metadata, nil
+}
+
+// parseFrontMatter is a helper function that returns the front matter of
+// the template and the body of the template after the front matter. The
+// front matter is replaced by empty lines in the body so the lines in
+// errors for the body match the lines in the template.
+func parseFrontMatter(tmpl string) (*frontMatter, string, error) {
+	lines := strings.SplitAfter(tmpl, "\n")
+
+	// check if the template starts with the front matter delimiter
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != frontMatterDelimiter {
+		return nil, tmpl, nil
+	}
+
+	// find the delimiter that ends the front matter
+	end := -1
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == frontMatterDelimiter {
+			end = i
+
+			break
+		}
+	}
+
+	if end < 0 {
+		return nil, tmpl, nil
+	}
+
+	block := strings.Join(lines[1:end], "")
+
+	// check the block only contains the front matter keys since a
+	// template may start with a yaml document separated by "---"
+	keys := make(map[string]interface{})
+
+	err := yaml.Unmarshal([]byte(block), &keys)
+	if err != nil || len(keys) == 0 {
+		return nil, tmpl, nil
+	}
+
+	for key := range keys {
+		if !frontMatterKeys[key] {
+			return nil, tmpl, nil
+		}
+	}
+
+	fm := new(frontMatter)
+
+	err = yaml.Unmarshal([]byte(block), fm)
+	if err != nil {
+		return nil, tmpl, fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	body := strings.Repeat("\n", end+1) + strings.Join(lines[end+1:], "")
+
+	return fm, body, nil
+}
+
+// prepare is a helper function that provides the metadata and the schema
+// declared in the front matter to the prepare function from the options
+// and returns the variables for rendering the template. The provided
+// variables are returned when no prepare function is set.
+//
+// nolint: lll // ignore long line length due to parameters
+func (o *Options) prepare(fm *frontMatter, vars map[string]interface{}) (map[string]interface{}, error) {
+	if o == nil || o.Prepare == nil {
+		return vars, nil
+	}
+
+	// check if the template has front matter
+	if fm == nil {
+		return o.Prepare(nil, nil)
+	}
+
+	return o.Prepare(fm.Metadata, fm.Variables)
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-vela/compiler/template"
+	"github.com/go-vela/types/raw"
+	"github.com/go-vela/types/yaml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNative_Schema(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    template.Schema
+		wantErr bool
+	}{
+		{
+			name: "front matter",
+			tmpl: `---
+variables:
+  image:
+    type: string
+    required: true
+    description: image for the step
+  tag:
+    default: latest
+---
+steps: []
+`,
+			want: template.Schema{
+				"image": {Type: "string", Required: true, Description: "image for the step"},
+				"tag":   {Default: "latest"},
+			},
+		},
+		{
+			name: "no front matter",
+			tmpl: "steps: []\n",
+		},
+		{
+			name: "yaml document",
+			tmpl: "---\nsteps: []\n---\nsecrets: []\n",
+		},
+		{
+			name: "unterminated",
+			tmpl: "---\nvariables: {}\n",
+		},
+		{
+			name:    "invalid",
+			tmpl:    "---\nvariables: [image]\n---\nsteps: []\n",
+			wantErr: true,
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got, err := Schema(test.tmpl)
+
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Schema for %s should have returned err", test.name)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Schema for %s returned err: %v", test.name, err)
+		}
+
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Schema for %s mismatch (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestNative_RenderStep_FrontMatter(t *testing.T) {
+	// setup types
+	tmpl := `---
+variables:
+  image:
+    type: string
+---
+steps:
+  - name: build
+    image: {{ .image }}
+    commands: [{{ .missing }]
+`
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template: yaml.StepTemplate{
+			Name:      "schema",
+			Variables: map[string]interface{}{"image": "alpine"},
+		},
+	}
+
+	// run test
+	_, _, _, _, err := RenderStepWithContext(context.Background(), tmpl, step)
+
+	// verify the lines in errors match the lines in the template
+	if err == nil || !strings.Contains(err.Error(), "sample:9:") {
+		t.Errorf("RenderStepWithContext returned err %v, want error on line 9", err)
+	}
+
+	step.Template.Variables = map[string]interface{}{"image": "alpine"}
+
+	got, _, _, _, err := RenderStepWithContext(context.Background(), strings.Replace(tmpl, "[{{ .missing }]", "[ls]", 1), step)
+	if err != nil {
+		t.Errorf("RenderStepWithContext returned err: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Image != "alpine" {
+		t.Errorf("RenderStepWithContext is %v, want step with image alpine", got)
+	}
+}
+
+func TestNative_Metadata(t *testing.T) {
+	// setup types
+	tmpl := `---
+metadata:
+  version: 1.2.0
+  deprecated: true
+  replacement: sample@v2
+  min_compiler_version: 0.10.0
+---
+steps: []
+`
+
+	want := &template.Metadata{
+		Version:            "1.2.0",
+		Deprecated:         true,
+		Replacement:        "sample@v2",
+		MinCompilerVersion: "0.10.0",
+	}
+
+	// run test
+	got, err := Metadata(tmpl)
+	if err != nil {
+		t.Errorf("Metadata returned err: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Metadata mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = Metadata("steps: []\n")
+	if err != nil {
+		t.Errorf("Metadata returned err: %v", err)
+	}
+
+	if got != nil {
+		t.Errorf("Metadata is %v, want nil", got)
+	}
+}
+
+func TestNative_RenderStepWithOptions_Prepare(t *testing.T) {
+	// setup types
+	tmpl := `---
+metadata:
+  version: 1.2.0
+variables:
+  image:
+    type: string
+    required: true
+---
+steps:
+  - name: build
+    image: {{ .image }}
+`
+
+	wantMetadata := &template.Metadata{Version: "1.2.0"}
+
+	wantSchema := template.Schema{
+		"image": {Type: "string", Required: true},
+	}
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template: yaml.StepTemplate{
+			Name:      "schema",
+			Variables: map[string]interface{}{"image": "ignored"},
+		},
+	}
+
+	opts := &Options{
+		Prepare: func(metadata *template.Metadata, schema template.Schema) (map[string]interface{}, error) {
+			if diff := cmp.Diff(wantMetadata, metadata); diff != "" {
+				t.Errorf("Prepare metadata mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(wantSchema, schema); diff != "" {
+				t.Errorf("Prepare schema mismatch (-want +got):\n%s", diff)
+			}
+
+			return map[string]interface{}{"image": "alpine"}, nil
+		},
+	}
+
+	// run test
+	got, err := RenderStepWithOptions(context.Background(), tmpl, step, opts)
+	if err != nil {
+		t.Errorf("RenderStepWithOptions returned err: %v", err)
+	}
+
+	if len(got.Build.Steps) != 1 || got.Build.Steps[0].Image != "alpine" {
+		t.Errorf("RenderStepWithOptions is %v, want step with image alpine", got.Build.Steps)
+	}
+}
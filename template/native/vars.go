@@ -0,0 +1,175 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	vela "github.com/go-vela/compiler/template"
+)
+
+// Options represents the optional settings for rendering a template.
+type Options struct {
+	// Strict fails rendering the template when the template references
+	// a variable that wasn't provided, i.e. missingkey=error, or when
+	// a provided variable is never referenced by the template.
+	Strict bool
+	// Warn receives the non-fatal problems found while rendering
+	// the template, i.e. unused variables when not in strict mode.
+	Warn func(message string)
+	// Prepare receives the metadata and the schema declared in the
+	// front matter of the template before the template is rendered
+	// and returns the variables for rendering the template.
+	Prepare vela.PrepareFunc
+}
+
+// unusedVars is a helper function that returns an error with the sorted
+// names of the provided variables that are never referenced by the template.
+func unusedVars(t *template.Template, name string, vars map[string]interface{}) error {
+	used := make(map[string]bool)
+
+	// capture the variables referenced by every template defined in the template
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+			continue
+		}
+
+		// the template passes the variables as a whole
+		// so all variables are considered referenced
+		if referenceVars(tmpl.Tree.Root, true, used) {
+			return nil
+		}
+	}
+
+	unused := []string{}
+
+	for key := range vars {
+		if !used[key] {
+			unused = append(unused, key)
+		}
+	}
+
+	if len(unused) == 0 {
+		return nil
+	}
+
+	sort.Strings(unused)
+
+	return fmt.Errorf("template %s has unused variables: %s", name, strings.Join(unused, ", "))
+}
+
+// referenceVars is a helper function that captures the names of the variables
+// referenced by the node, i.e. {{ .foo }}, {{ $.foo }} or {{ index . "foo" }}.
+// The root flag indicates if the dot is the variables at the node, which isn't
+// the case inside the body of a range or with. It returns true when the node
+// references the variables as a whole, i.e. {{ toYaml . }}, since every
+// variable could be referenced.
+//
+// nolint: gocyclo,funlen // ignore cyclomatic complexity and function length
+func referenceVars(node parse.Node, root bool, used map[string]bool) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+
+		for _, child := range n.Nodes {
+			if referenceVars(child, root, used) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return referenceVars(n.Pipe, root, used)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+
+		for _, cmd := range n.Cmds {
+			if referenceVars(cmd, root, used) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		args := n.Args
+
+		// capture the keys provided to index, i.e. {{ index . "foo" }}
+		if len(args) > 2 && isIdentifier(args[0], "index") && isRoot(args[1], root) {
+			if key, ok := args[2].(*parse.StringNode); ok {
+				used[key.Text] = true
+
+				args = args[2:]
+			}
+		}
+
+		for _, arg := range args {
+			if referenceVars(arg, root, used) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		if root {
+			used[n.Ident[0]] = true
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] != "$" {
+			return false
+		}
+
+		// the root variable references the variables as a whole
+		if len(n.Ident) == 1 {
+			return true
+		}
+
+		used[n.Ident[1]] = true
+	case *parse.ChainNode:
+		return referenceVars(n.Node, root, used)
+	case *parse.DotNode:
+		return root
+	case *parse.IfNode:
+		return referenceVars(n.Pipe, root, used) ||
+			referenceVars(n.List, root, used) ||
+			referenceVars(n.ElseList, root, used)
+	case *parse.RangeNode:
+		// the dot is the element inside the body of the range
+		return referenceVars(n.Pipe, root, used) ||
+			referenceVars(n.List, false, used) ||
+			referenceVars(n.ElseList, root, used)
+	case *parse.WithNode:
+		// the dot is the value inside the body of the with
+		return referenceVars(n.Pipe, root, used) ||
+			referenceVars(n.List, false, used) ||
+			referenceVars(n.ElseList, root, used)
+	case *parse.TemplateNode:
+		return referenceVars(n.Pipe, root, used)
+	}
+
+	return false
+}
+
+// isIdentifier is a helper function that checks
+// if the node is the function with the name.
+func isIdentifier(node parse.Node, name string) bool {
+	ident, ok := node.(*parse.IdentifierNode)
+
+	return ok && ident.Ident == name
+}
+
+// isRoot is a helper function that checks if the
+// node is the variables, i.e. the root dot or $.
+func isRoot(node parse.Node, root bool) bool {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return root
+	case *parse.VariableNode:
+		return len(n.Ident) == 1 && n.Ident[0] == "$"
+	}
+
+	return false
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package native
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-vela/compiler/template"
+
+	"github.com/go-vela/types/raw"
+	"github.com/go-vela/types/yaml"
+)
+
+func TestNative_RenderStepWithOptions_Strict(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		name    string
+		tmpl    string
+		vars    map[string]interface{}
+		strict  bool
+		wantErr string
+	}{
+		{
+			name:   "lenient",
+			tmpl:   `steps: [{name: build, image: "{{ .imag }}"}]`,
+			vars:   map[string]interface{}{"image": "alpine"},
+			strict: false,
+		},
+		{
+			name:    "missing key",
+			tmpl:    `steps: [{name: build, image: "{{ .imag }}{{ .image }}"}]`,
+			vars:    map[string]interface{}{"image": "alpine"},
+			strict:  true,
+			wantErr: `map has no entry for key "imag"`,
+		},
+		{
+			name:    "unused",
+			tmpl:    `steps: [{name: build, image: "{{ .image }}"}]`,
+			vars:    map[string]interface{}{"image": "alpine", "tag": "latest", "pull": "always"},
+			strict:  true,
+			wantErr: "template strict has unused variables: pull, tag",
+		},
+		{
+			name: "referenced",
+			tmpl: `steps:
+{{ range $i, $cmd := .commands }}
+  - name: {{ $.prefix }}{{ $i }}
+    image: {{ index $ "image" | default "alpine" }}
+    commands: [{{ $cmd }}]
+{{ end }}
+{{ with .extra }}  - {{ .name }}{{ end }}`,
+			vars: map[string]interface{}{
+				"commands": []interface{}{"ls"},
+				"prefix":   "step_",
+				"image":    "alpine",
+				"extra":    nil,
+			},
+			strict: true,
+		},
+		{
+			name:    "range body",
+			tmpl:    `steps: [{{ range .commands }}{name: "{{ .name }}", image: alpine},{{ end }}]`,
+			vars:    map[string]interface{}{"commands": []interface{}{map[string]interface{}{"name": "ls"}}, "name": "foo"},
+			strict:  true,
+			wantErr: "template strict has unused variables: name",
+		},
+		{
+			name:   "whole variables",
+			tmpl:   `{{ $vars := . }}steps: [{name: build, image: "{{ $vars.image }}"}]`,
+			vars:   map[string]interface{}{"image": "alpine", "tag": "latest"},
+			strict: true,
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		step := &yaml.Step{
+			Name:        "sample",
+			Environment: raw.StringSliceMap{},
+			Template: yaml.StepTemplate{
+				Name:      "strict",
+				Variables: test.vars,
+			},
+		}
+
+		_, err := RenderStepWithOptions(context.Background(), test.tmpl, step, &Options{Strict: test.strict})
+
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("RenderStepWithOptions for %s returned err %v, want %s", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("RenderStepWithOptions for %s returned err: %v", test.name, err)
+		}
+	}
+}
+
+func TestNative_RenderStepWithOptions_Warn(t *testing.T) {
+	// setup types
+	warnings := []string{}
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template: yaml.StepTemplate{
+			Name:      "lenient",
+			Variables: map[string]interface{}{"image": "alpine", "tag": "latest"},
+		},
+	}
+
+	opts := &Options{
+		Warn: func(message string) {
+			warnings = append(warnings, message)
+		},
+	}
+
+	// run test
+	_, err := RenderStepWithOptions(context.Background(), `steps: [{name: build, image: "{{ .image }}"}]`, step, opts)
+	if err != nil {
+		t.Errorf("RenderStepWithOptions returned err: %v", err)
+	}
+
+	want := []string{"template lenient has unused variables: tag"}
+
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("RenderStepWithOptions warnings are %v, want %v", warnings, want)
+	}
+}
+
+func TestNative_RenderStepWithOptions_WarnDefaults(t *testing.T) {
+	// setup types
+	warnings := []string{}
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template: yaml.StepTemplate{
+			Name:      "defaults",
+			Variables: map[string]interface{}{"image": "alpine", "tag": "latest"},
+		},
+	}
+
+	opts := &Options{
+		Warn: func(message string) {
+			warnings = append(warnings, message)
+		},
+		// apply a default from the schema that the template never references
+		Prepare: func(metadata *template.Metadata, schema template.Schema) (map[string]interface{}, error) {
+			return map[string]interface{}{"image": "alpine", "tag": "latest", "pull": "always"}, nil
+		},
+	}
+
+	// run test
+	_, err := RenderStepWithOptions(context.Background(), `steps: [{name: build, image: "{{ .image }}"}]`, step, opts)
+	if err != nil {
+		t.Errorf("RenderStepWithOptions returned err: %v", err)
+	}
+
+	want := []string{"template defaults has unused variables: tag"}
+
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("RenderStepWithOptions warnings are %v, want %v", warnings, want)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Variable represents the declaration of
+// a variable accepted by a template.
+type Variable struct {
+	Type        string      `yaml:"type,omitempty"`
+	Required    bool        `yaml:"required,omitempty"`
+	Default     interface{} `yaml:"default,omitempty"`
+	Description string      `yaml:"description,omitempty"`
+}
+
+// Schema represents the variables accepted by a template by name.
+type Schema map[string]*Variable
+
+// Validate verifies the variables provided to a template against the schema
+// and returns a copy of the variables with the defaults from the schema applied.
+func (s Schema) Validate(vars map[string]interface{}) (map[string]interface{}, error) {
+	problems := []string{}
+
+	// capture the sorted names for a consistent order of the problems
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	result := make(map[string]interface{})
+
+	for _, name := range names {
+		v := s[name]
+		if v == nil {
+			v = new(Variable)
+		}
+
+		value, ok := vars[name]
+		if !ok || value == nil {
+			if v.Required {
+				problems = append(problems, fmt.Sprintf("variable `%s` is required", name))
+
+				continue
+			}
+
+			if v.Default != nil {
+				result[name] = v.Default
+			}
+
+			continue
+		}
+
+		err := v.check(value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("variable `%s` %v", name, err))
+
+			continue
+		}
+
+		result[name] = value
+	}
+
+	// check for variables that aren't declared by the schema
+	undeclared := []string{}
+
+	for name := range vars {
+		if _, ok := s[name]; !ok {
+			undeclared = append(undeclared, name)
+		}
+	}
+
+	sort.Strings(undeclared)
+
+	for _, name := range undeclared {
+		problems = append(problems, fmt.Sprintf("variable `%s` is not declared by the template", name))
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return result, nil
+}
+
+// check is a helper function that verifies
+// the value matches the type of the variable.
+//
+// nolint: gocyclo // ignore cyclomatic complexity
+func (v *Variable) check(value interface{}) error {
+	kind := reflect.ValueOf(value).Kind()
+
+	switch strings.ToLower(v.Type) {
+	case "", "any":
+		return nil
+	case "string", "str":
+		if kind == reflect.String {
+			return nil
+		}
+
+		return fmt.Errorf("must be a string")
+	case "integer", "int":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return nil
+		}
+
+		return fmt.Errorf("must be an integer")
+	case "number", "float":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return nil
+		}
+
+		return fmt.Errorf("must be a number")
+	case "boolean", "bool":
+		if kind == reflect.Bool {
+			return nil
+		}
+
+		return fmt.Errorf("must be a boolean")
+	case "list", "array":
+		if kind == reflect.Slice || kind == reflect.Array {
+			return nil
+		}
+
+		return fmt.Errorf("must be a list")
+	case "map", "dict", "object":
+		if kind == reflect.Map {
+			return nil
+		}
+
+		return fmt.Errorf("must be a map")
+	default:
+		return fmt.Errorf("has unsupported type %s in the template schema", v.Type)
+	}
+}
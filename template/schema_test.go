@@ -0,0 +1,107 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTemplate_Schema_Validate(t *testing.T) {
+	// setup types
+	schema := Schema{
+		"go_version": {Type: "string", Required: true, Description: "version of Go"},
+		"race":       {Type: "boolean", Default: false},
+		"retries":    {Type: "integer", Default: 3},
+		"timeout":    {Type: "number"},
+		"tags":       {Type: "list"},
+		"env":        {Type: "map"},
+		"extra":      {},
+	}
+
+	// setup tests
+	tests := []struct {
+		name    string
+		vars    map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			vars: map[string]interface{}{"go_version": "1.17"},
+			want: map[string]interface{}{"go_version": "1.17", "race": false, "retries": 3},
+		},
+		{
+			name: "all",
+			vars: map[string]interface{}{
+				"go_version": "1.17",
+				"race":       true,
+				"retries":    1,
+				"timeout":    1.5,
+				"tags":       []interface{}{"a"},
+				"env":        map[interface{}]interface{}{"FOO": "bar"},
+				"extra":      "anything",
+			},
+			want: map[string]interface{}{
+				"go_version": "1.17",
+				"race":       true,
+				"retries":    1,
+				"timeout":    1.5,
+				"tags":       []interface{}{"a"},
+				"env":        map[interface{}]interface{}{"FOO": "bar"},
+				"extra":      "anything",
+			},
+		},
+		{
+			name:    "required",
+			vars:    map[string]interface{}{},
+			wantErr: "variable `go_version` is required",
+		},
+		{
+			name:    "invalid types",
+			vars:    map[string]interface{}{"go_version": 1.17, "retries": "3", "tags": "a"},
+			wantErr: "variable `go_version` must be a string; variable `retries` must be an integer; variable `tags` must be a list",
+		},
+		{
+			name:    "undeclared",
+			vars:    map[string]interface{}{"go_version": "1.17", "go_verison": "1.16"},
+			wantErr: "variable `go_verison` is not declared by the template",
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		got, err := schema.Validate(test.vars)
+
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("Validate for %s returned err %v, want %s", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Validate for %s returned err: %v", test.name, err)
+		}
+
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Validate for %s mismatch (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestTemplate_Schema_Validate_UnsupportedType(t *testing.T) {
+	// setup types
+	schema := Schema{"image": {Type: "str1ng"}}
+
+	// run test
+	_, err := schema.Validate(map[string]interface{}{"image": "alpine"})
+	if err == nil || !strings.Contains(err.Error(), "variable `image` has unsupported type str1ng") {
+		t.Errorf("Validate returned err %v, want unsupported type", err)
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-vela/types/raw"
+	types "github.com/go-vela/types/yaml"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// nolint: gochecknoglobals // types are only used for comparisons
+var (
+	stringSliceType    = reflect.TypeOf(raw.StringSlice{})
+	stringSliceMapType = reflect.TypeOf(raw.StringSliceMap{})
+)
+
+// constructor represents a Starlark function that creates a typed
+// pipeline object, i.e. step(...), after checking the name and type
+// of every field against the yaml representation of the object.
+type constructor struct {
+	name string
+	// fields contains the type for each field by yaml name
+	fields map[string]reflect.Type
+	// required contains the fields required for the object where
+	// each entry contains the alternatives that satisfy the entry
+	required [][]string
+}
+
+// newConstructor is a helper function that returns the Starlark function
+// creating the object with the fields from the yaml tags of the provided value.
+func newConstructor(name string, v interface{}, required ...[]string) *starlark.Builtin {
+	c := &constructor{
+		name:     name,
+		fields:   make(map[string]reflect.Type),
+		required: required,
+	}
+
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if len(tag) == 0 || tag == "-" {
+			continue
+		}
+
+		c.fields[tag] = t.Field(i).Type
+	}
+
+	return starlark.NewBuiltin(name, c.call)
+}
+
+// call creates the object from the keyword arguments. Fields set to None are
+// omitted from the object so they can be set conditionally in a template.
+//
+// nolint: lll // ignore long line length due to parameters
+func (c *constructor) call(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) > 0 {
+		return nil, fmt.Errorf("%s: unexpected positional arguments, fields must be provided by name", c.name)
+	}
+
+	d := make(starlark.StringDict)
+
+	for _, kwarg := range kwargs {
+		name := string(kwarg[0].(starlark.String))
+		value := kwarg[1]
+
+		t, ok := c.fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown field %s (expected one of %s)", c.name, name, strings.Join(c.names(), ", "))
+		}
+
+		if value == starlark.None {
+			continue
+		}
+
+		err := checkField(t, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: field %s %w", c.name, name, err)
+		}
+
+		d[name] = value
+	}
+
+	// check the required fields are provided for the object
+	for _, alternatives := range c.required {
+		found := false
+
+		for _, name := range alternatives {
+			if _, ok := d[name]; ok {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%s: missing required field %s", c.name, strings.Join(alternatives, " or "))
+		}
+	}
+
+	return starlarkstruct.FromStringDict(starlark.String(c.name), d), nil
+}
+
+// names is a helper function that returns the sorted names of the fields.
+func (c *constructor) names() []string {
+	names := make(starlark.StringDict)
+	for name := range c.fields {
+		names[name] = starlark.None
+	}
+
+	return names.Keys()
+}
+
+// checkField is a helper function that verifies the Starlark
+// value can be unmarshaled to the type of the yaml field.
+//
+// nolint: gocyclo // ignore cyclomatic complexity
+func checkField(t reflect.Type, v starlark.Value) error {
+	var want string
+
+	// the variables for the template are passed as a dict
+	v = unwrapVars(v)
+
+	switch {
+	case t == stringSliceType:
+		if _, ok := v.(starlark.String); ok || isStringList(v) {
+			return nil
+		}
+
+		want = "a string or list of strings"
+	case t == stringSliceMapType:
+		if _, ok := v.(*starlark.Dict); ok || isStringList(v) {
+			return nil
+		}
+
+		want = "a dict or list of strings"
+	case t.Kind() == reflect.String:
+		if _, ok := v.(starlark.String); ok {
+			return nil
+		}
+
+		want = "a string"
+	case t.Kind() == reflect.Bool:
+		if _, ok := v.(starlark.Bool); ok {
+			return nil
+		}
+
+		want = "a bool"
+	case t.Kind() == reflect.Map:
+		if _, ok := v.(*starlark.Dict); ok {
+			return nil
+		}
+
+		want = "a dict"
+	case t.Kind() == reflect.Slice:
+		switch v.(type) {
+		case *starlark.List, starlark.Tuple:
+			return nil
+		}
+
+		want = "a list"
+	case t.Kind() == reflect.Struct:
+		switch v.(type) {
+		case *starlark.Dict, *starlarkstruct.Struct:
+			return nil
+		}
+
+		want = "a dict"
+	default:
+		return nil
+	}
+
+	return fmt.Errorf("must be %s, not %s", want, v.Type())
+}
+
+// isStringList is a helper function that checks
+// if the value is a list or tuple of strings.
+func isStringList(v starlark.Value) bool {
+	switch v.(type) {
+	case *starlark.List, starlark.Tuple:
+	default:
+		return false
+	}
+
+	iter := starlark.Iterate(v)
+	defer iter.Done()
+
+	var item starlark.Value
+	for iter.Next(&item) {
+		if _, ok := item.(starlark.String); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nolint: gochecknoglobals // constructors are shared between threads
+var (
+	// stepConstructor creates a step with step(name = ..., image = ...).
+	stepConstructor = newConstructor("step", types.Step{}, []string{"name"}, []string{"image", "template"})
+
+	// stageConstructor creates a stage with stage(steps = [...]). The name is
+	// optional since stages are returned in a dict keyed by the stage name.
+	stageConstructor = newConstructor("stage", types.Stage{}, []string{"steps"})
+
+	// serviceConstructor creates a service with service(name = ..., image = ...).
+	serviceConstructor = newConstructor("service", types.Service{}, []string{"name"}, []string{"image"})
+
+	// secretConstructor creates a secret with secret(name = ...).
+	secretConstructor = newConstructor("secret", types.Secret{}, []string{"name"})
+)
@@ -0,0 +1,178 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-vela/types/raw"
+	"github.com/go-vela/types/yaml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStarlark_constructors(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		name    string
+		call    string
+		wantErr string
+	}{
+		{
+			name: "step",
+			call: `step(name = "build", image = "alpine", commands = ["echo hello"], environment = {"FOO": "bar"}, pull = None)`,
+		},
+		{
+			name: "step with template",
+			call: `step(name = "build", template = {"name": "gradle"})`,
+		},
+		{
+			name: "stage",
+			call: `stage(needs = "test", steps = [step(name = "build", image = "alpine")])`,
+		},
+		{
+			name: "service",
+			call: `service(name = "redis", image = "redis", ports = ["6379:6379"])`,
+		},
+		{
+			name: "secret",
+			call: `secret(name = "docker_password", key = "org/repo/docker/password", engine = "native")`,
+		},
+		{
+			name:    "unknown field",
+			call:    `step(name = "build", imag = "alpine")`,
+			wantErr: "step: unknown field imag (expected one of commands, detach, entrypoint,",
+		},
+		{
+			name:    "invalid type",
+			call:    `step(name = "build", image = "alpine", commands = 1)`,
+			wantErr: "step: field commands must be a string or list of strings, not int",
+		},
+		{
+			name:    "invalid list item",
+			call:    `service(name = "redis", image = "redis", ports = [6379])`,
+			wantErr: "service: field ports must be a string or list of strings, not list",
+		},
+		{
+			name:    "missing required field",
+			call:    `step(name = "build")`,
+			wantErr: "step: missing required field image or template",
+		},
+		{
+			name:    "positional arguments",
+			call:    `secret("docker_password")`,
+			wantErr: "secret: unexpected positional arguments",
+		},
+	}
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template:    yaml.StepTemplate{Name: "constructors"},
+	}
+
+	// run tests
+	for _, test := range tests {
+		tmpl := `
+def make():
+    return ` + test.call + `
+
+def main(ctx):
+    make()
+    return {"steps": []}
+`
+
+		_, _, _, _, err := RenderStepWithContext(context.Background(), tmpl, step)
+
+		if len(test.wantErr) == 0 {
+			if err != nil {
+				t.Errorf("RenderStep for %s returned err: %v", test.name, err)
+			}
+
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("RenderStep for %s returned err %v, want %s", test.name, err, test.wantErr)
+
+			continue
+		}
+
+		// verify the error contains the call stack for the template
+		for _, frame := range []string{"Traceback", "constructors:3:", "in make", "constructors:6:", "in main"} {
+			if !strings.Contains(err.Error(), frame) {
+				t.Errorf("RenderStep for %s returned err %v, want call stack with %s", test.name, err, frame)
+			}
+		}
+	}
+}
+
+func TestStarlark_RenderBuild_Constructors(t *testing.T) {
+	// setup types
+	b := `
+def main(ctx):
+    return {
+        "version": "1",
+        "secrets": [secret(name = "docker_password", key = "org/repo/docker/password")],
+        "services": [service(name = "redis", image = "redis")],
+        "stages": {
+            "test": stage(steps = [
+                step(name = "test", image = "alpine", commands = ["echo test"], secrets = ["docker_password"]),
+            ]),
+            "build": stage(needs = ["test"], steps = [
+                step(name = "build", image = "alpine", commands = "echo build"),
+            ]),
+        },
+    }
+`
+
+	want := &yaml.Build{
+		Version: "1",
+		Secrets: yaml.SecretSlice{
+			{Name: "docker_password", Key: "org/repo/docker/password", Engine: "native", Type: "repo"},
+		},
+		Services: yaml.ServiceSlice{
+			{Name: "redis", Image: "redis", Pull: "not_present"},
+		},
+		Stages: yaml.StageSlice{
+			{
+				Name:  "test",
+				Needs: raw.StringSlice{"clone"},
+				Steps: yaml.StepSlice{
+					{
+						Name:     "test",
+						Image:    "alpine",
+						Commands: raw.StringSlice{"echo test"},
+						Secrets:  yaml.StepSecretSlice{{Source: "docker_password", Target: "docker_password"}},
+						Pull:     "not_present",
+					},
+				},
+			},
+			{
+				Name:  "build",
+				Needs: raw.StringSlice{"test", "clone"},
+				Steps: yaml.StepSlice{
+					{
+						Name:     "build",
+						Image:    "alpine",
+						Commands: raw.StringSlice{"echo build"},
+						Pull:     "not_present",
+					},
+				},
+			},
+		},
+	}
+
+	// run test
+	got, err := RenderBuild(b, map[string]string{})
+	if err != nil {
+		t.Errorf("RenderBuild returned err: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RenderBuild mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+// moduleKey is the thread local key for the ID of the
+// module being executed by the thread. The ID is empty
+// for the template being rendered.
+const moduleKey = "vela.module"
+
+// Loader represents the interface for resolving and
+// capturing the modules loaded by templates with load().
+type Loader interface {
+	// Resolve defines a function that returns the unique ID for
+	// the module loaded by the module with the provided ID.
+	Resolve(from, module string) (string, error)
+	// Source defines a function that captures
+	// the source for the module with the provided ID.
+	Source(ctx context.Context, id string) ([]byte, error)
+}
+
+// Modules represents the modules loaded by templates. The modules are
+// cached by ID so every template rendered with the same Modules only
+// executes a module once. Modules is not safe for concurrent use.
+type Modules struct {
+	entries map[string]*module
+	stack   []string
+}
+
+// module represents the result from executing a loaded module.
+type module struct {
+	globals starlark.StringDict
+	err     error
+	loading bool
+}
+
+// NewModules returns an empty cache for loaded modules.
+func NewModules() *Modules {
+	return &Modules{entries: make(map[string]*module)}
+}
+
+// load returns the load function for a thread that resolves and
+// executes the modules with the loader, caching the results and
+// failing when a module is loaded while it is still being executed.
+//
+// nolint: lll // ignore long line length due to return args
+func (m *Modules) load(ctx context.Context, o *Options) func(*starlark.Thread, string) (starlark.StringDict, error) {
+	return func(thread *starlark.Thread, name string) (starlark.StringDict, error) {
+		from, _ := thread.Local(moduleKey).(string)
+
+		id, err := o.Loader.Resolve(from, name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve module %s: %w", name, err)
+		}
+
+		// check if the module has already been loaded
+		if e, ok := m.entries[id]; ok {
+			if e.loading {
+				chain := append(append([]string{}, m.stack...), id)
+
+				return nil, fmt.Errorf("cycle detected in load: %s", strings.Join(chain, " -> "))
+			}
+
+			return e.globals, e.err
+		}
+
+		e := &module{loading: true}
+		m.entries[id] = e
+		m.stack = append(m.stack, id)
+
+		e.globals, e.err = m.exec(ctx, thread, id, o)
+
+		e.loading = false
+		m.stack = m.stack[:len(m.stack)-1]
+
+		return e.globals, e.err
+	}
+}
+
+// exec captures the source for the module and
+// executes it in a new thread with the same limits.
+//
+// nolint: lll // ignore long line length due to parameters
+func (m *Modules) exec(ctx context.Context, parent *starlark.Thread, id string, o *Options) (starlark.StringDict, error) {
+	src, err := o.Loader.Source(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to capture module %s: %w", id, err)
+	}
+
+	thread := &starlark.Thread{Name: id, Load: parent.Load}
+	thread.SetLocal(moduleKey, id)
+
+	// cancel the thread when the context is done
+	stop := cancelOnDone(ctx, thread)
+	defer stop()
+
+	// limit the steps of the module the same as the template
+	thread.SetMaxExecutionSteps(o.maxExecutionSteps())
+
+	globals, err := starlark.ExecFile(thread, id, src, predeclared)
+	if err != nil {
+		if thread.ExecutionSteps() >= o.maxExecutionSteps() {
+			return nil, fmt.Errorf("%w for module %s (limit of %d steps)", ErrExecutionStepLimit, id, o.maxExecutionSteps())
+		}
+
+		return nil, err
+	}
+
+	// freeze the globals so the module can be shared between templates
+	globals.Freeze()
+
+	return globals, nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/go-vela/types/raw"
+	"github.com/go-vela/types/yaml"
+)
+
+// mapLoader is a Starlark loader that captures modules from a map.
+type mapLoader struct {
+	modules map[string]string
+	calls   map[string]int
+}
+
+func (l *mapLoader) Resolve(from, module string) (string, error) {
+	return path.Join(path.Dir(from), module), nil
+}
+
+func (l *mapLoader) Source(ctx context.Context, id string) ([]byte, error) {
+	l.calls[id]++
+
+	src, ok := l.modules[id]
+	if !ok {
+		return nil, fmt.Errorf("module %s not found", id)
+	}
+
+	return []byte(src), nil
+}
+
+func TestStarlark_RenderStepWithOptions_Load(t *testing.T) {
+	// setup types
+	tmpl := `
+load("lib/helpers.star", "make_step")
+
+def main(ctx):
+    return {"steps": [make_step("build")]}
+`
+
+	loader := &mapLoader{
+		calls: make(map[string]int),
+		modules: map[string]string{
+			"lib/helpers.star": `
+load("image.star", "IMAGE")
+
+def make_step(name):
+    return {"name": name, "image": IMAGE, "commands": ["echo " + name]}
+`,
+			"lib/image.star": `IMAGE = "alpine:latest"`,
+		},
+	}
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template:    yaml.StepTemplate{Name: "helpers"},
+	}
+
+	opts := &Options{Loader: loader, Modules: NewModules()}
+
+	// run test twice to verify the modules are cached
+	for i := 0; i < 2; i++ {
+		got, err := RenderStepWithOptions(context.Background(), tmpl, step, opts)
+		if err != nil {
+			t.Errorf("RenderStepWithOptions returned err: %v", err)
+		}
+
+		if len(got.Build.Steps) != 1 || got.Build.Steps[0].Name != "sample_build" || got.Build.Steps[0].Image != "alpine:latest" {
+			t.Errorf("RenderStepWithOptions is %v, want step sample_build with image alpine:latest", got.Build.Steps)
+		}
+	}
+
+	for id, calls := range loader.calls {
+		if calls != 1 {
+			t.Errorf("RenderStepWithOptions captured module %s %d times, want 1", id, calls)
+		}
+	}
+}
+
+func TestStarlark_RenderStepWithOptions_LoadCycle(t *testing.T) {
+	// setup types
+	tmpl := `
+load("a.star", "A")
+
+def main(ctx):
+    return {"steps": []}
+`
+
+	loader := &mapLoader{
+		calls: make(map[string]int),
+		modules: map[string]string{
+			"a.star": `load("b.star", "B")
+A = B`,
+			"b.star": `load("a.star", "A")
+B = A`,
+		},
+	}
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template:    yaml.StepTemplate{Name: "cycle"},
+	}
+
+	_, err := RenderStepWithOptions(context.Background(), tmpl, step, &Options{Loader: loader})
+	if err == nil {
+		t.Errorf("RenderStepWithOptions should have returned err")
+	}
+
+	if !strings.Contains(err.Error(), "cycle detected in load: a.star -> b.star -> a.star") {
+		t.Errorf("RenderStepWithOptions returned err %v, want load cycle", err)
+	}
+}
+
+func TestStarlark_RenderStepWithOptions_NoLoader(t *testing.T) {
+	// setup types
+	tmpl := `
+load("helpers.star", "make_step")
+
+def main(ctx):
+    return {"steps": []}
+`
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template:    yaml.StepTemplate{Name: "helpers"},
+	}
+
+	_, err := RenderStepWithOptions(context.Background(), tmpl, step, nil)
+	if err == nil {
+		t.Errorf("RenderStepWithOptions should have returned err")
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	// embed the time zone database so the locations for
+	// the time module resolve on hosts without tzdata
+	_ "time/tzdata"
+
+	"go.starlark.net/lib/json"
+	"go.starlark.net/lib/math"
+	"go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// predeclared contains the modules and constructors available to every
+// template and module without calling load(). The modules are deterministic
+// and don't provide access to the file system, network or the current time.
+//
+// nolint: gochecknoglobals // modules are frozen and shared between threads
+var predeclared = newPredeclared()
+
+// newPredeclared is a helper function that creates the modules
+// and constructors available to every template and module.
+func newPredeclared() starlark.StringDict {
+	d := starlark.StringDict{
+		"json":   json.Module,
+		"math":   math.Module,
+		"re":     reModule,
+		"struct": starlark.NewBuiltin("struct", starlarkstruct.Make),
+		"time":   timeModule(),
+		"yaml":   yamlModule,
+
+		// constructors for the typed pipeline objects
+		"secret":  secretConstructor,
+		"service": serviceConstructor,
+		"stage":   stageConstructor,
+		"step":    stepConstructor,
+	}
+
+	d.Freeze()
+
+	return d
+}
+
+// timeModule is a helper function that returns the Starlark time
+// module without now() so rendering a template stays deterministic.
+func timeModule() *starlarkstruct.Module {
+	members := make(starlark.StringDict)
+
+	for name, member := range time.Module.Members {
+		if name == "now" {
+			continue
+		}
+
+		members[name] = member
+	}
+
+	return &starlarkstruct.Module{
+		Name:    time.Module.Name,
+		Members: members,
+	}
+}
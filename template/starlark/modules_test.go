@@ -0,0 +1,138 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-vela/types/raw"
+	"github.com/go-vela/types/yaml"
+	"go.starlark.net/starlark"
+)
+
+func TestStarlark_predeclared(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "json",
+			src:  `result = json.decode(json.encode({"b": [1, 2], "a": "c"}))`,
+			want: `{"a": "c", "b": [1, 2]}`,
+		},
+		{
+			name: "yaml encode",
+			src:  `result = yaml.encode({"b": [1, "true"], "a": None, "c": struct(x = 1.5)})`,
+			want: `"b:\n    - 1\n    - \"true\"\na: null\nc:\n    x: 1.5\n"`,
+		},
+		{
+			name: "yaml decode",
+			src:  "result = yaml.decode('b: [0x10, yes, 1.5, foo]\\na: &x {c: ~}\\nd: *x')",
+			want: `{"b": [16, "yes", 1.5, "foo"], "a": {"c": None}, "d": {"c": None}}`,
+		},
+		{
+			name:    "yaml decode invalid",
+			src:     `result = yaml.decode("a: [")`,
+			wantErr: true,
+		},
+		{
+			name: "re",
+			src: `result = [
+    re.match("^release/v[0-9]+$", "release/v12"),
+    re.find("[0-9]+", "v12.3"),
+    re.find_all("[0-9]+", "v12.3.4", 2),
+    re.groups("(\\w+)/(\\w+)?", "feature/"),
+    re.replace("(\\w+)@(\\w+)", "foo@bar", "$2@$1"),
+    re.split("[.]", "1.2.3"),
+    re.escape("a.b"),
+]`,
+			want: `[True, "12", ["12", "3"], ["feature/", "feature", None], "bar@foo", ["1", "2", "3"], "a\\.b"]`,
+		},
+		{
+			name:    "re invalid pattern",
+			src:     `result = re.match("(", "foo")`,
+			wantErr: true,
+		},
+		{
+			name: "math",
+			src:  `result = [math.ceil(1.2), math.pow(2, 3)]`,
+			want: `[2, 8.0]`,
+		},
+		{
+			name: "time",
+			src:  `result = time.from_timestamp(0).unix + int(time.parse_duration("1m") / time.second)`,
+			want: `60`,
+		},
+		{
+			name: "time location",
+			src:  `result = [time.is_valid_timezone("America/Chicago"), time.parse_time("2021-06-01T12:00:00Z").in_location("America/Chicago").hour]`,
+			want: `[True, 7]`,
+		},
+		{
+			name:    "time now",
+			src:     `result = time.now()`,
+			wantErr: true,
+		},
+		{
+			name: "struct",
+			src:  `result = struct(name = "foo").name`,
+			want: `"foo"`,
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		thread := &starlark.Thread{Name: test.name}
+
+		globals, err := starlark.ExecFile(thread, test.name, test.src, predeclared)
+
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ExecFile for %s should have returned err", test.name)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ExecFile for %s returned err: %v", test.name, err)
+
+			continue
+		}
+
+		got := globals["result"].String()
+
+		if got != test.want {
+			t.Errorf("ExecFile for %s is %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestStarlark_RenderStep_Struct(t *testing.T) {
+	// setup types
+	tmpl := `
+def main(ctx):
+    return {"steps": [struct(name = "build", image = "alpine", commands = ["echo hello"])]}
+`
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template:    yaml.StepTemplate{Name: "struct"},
+	}
+
+	got, _, _, _, err := RenderStepWithContext(context.Background(), tmpl, step)
+	if err != nil {
+		t.Errorf("RenderStepWithContext returned err: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Name != "sample_build" || got[0].Image != "alpine" {
+		t.Errorf("RenderStepWithContext is %v, want step sample_build with image alpine", got)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-vela/compiler/template"
+
+	"go.starlark.net/starlark"
+)
+
+// DefaultMaxExecutionSteps is the default limit for the steps of the thread
+// executing a template.
+//
+// arbitrarily limiting the steps of the thread to 5000 to help prevent infinite loops
+// may need to further investigate spawning a separate POSIX process if user input is problematic
+// see https://github.com/google/starlark-go/issues/160#issuecomment-466794230 for further details
+const DefaultMaxExecutionSteps uint64 = 5000
+
+var (
+	// ErrExecutionStepLimit defines the error type when the
+	// template exceeds the limit for the steps of the thread.
+	ErrExecutionStepLimit = errors.New("execution step limit exceeded")
+
+	// ErrExecutionTimeout defines the error type when the
+	// template exceeds the limit for the execution time.
+	ErrExecutionTimeout = errors.New("execution timeout exceeded")
+
+	// ErrOutputLimit defines the error type when the pipeline
+	// returned by the template exceeds the limit for the size.
+	ErrOutputLimit = errors.New("output size limit exceeded")
+)
+
+// Options represents the optional settings for rendering a template.
+type Options struct {
+	// Loader resolves and captures the modules loaded with load().
+	Loader Loader
+	// Modules caches the loaded modules between rendered templates.
+	Modules *Modules
+	// MaxExecutionSteps limits the steps of the thread executing the
+	// template and each loaded module, defaulting to DefaultMaxExecutionSteps.
+	MaxExecutionSteps uint64
+	// Timeout limits the execution time of the template, including
+	// the loaded modules. The execution time is unlimited when empty.
+	Timeout time.Duration
+	// MaxOutputSize limits the size in bytes of the pipeline
+	// returned by the template. The size is unlimited when empty.
+	MaxOutputSize int
+	// Strict fails rendering the template when a provided
+	// variable is never referenced by the template.
+	Strict bool
+	// Warn receives the non-fatal problems found while rendering
+	// the template, i.e. unused variables when not in strict mode.
+	Warn func(message string)
+	// Prepare receives the metadata and the schema declared by the
+	// vela_metadata and vela_schema globals of the template once the
+	// template is executed and returns the variables for calling main,
+	// so the template is only executed once for rendering.
+	Prepare template.PrepareFunc
+}
+
+// maxExecutionSteps is a helper function that
+// returns the limit for the steps of a thread.
+func (o *Options) maxExecutionSteps() uint64 {
+	if o == nil || o.MaxExecutionSteps == 0 {
+		return DefaultMaxExecutionSteps
+	}
+
+	return o.MaxExecutionSteps
+}
+
+// withTimeout is a helper function that returns a context
+// that is done when the execution timeout is exceeded.
+func (o *Options) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o == nil || o.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, o.Timeout)
+}
+
+// thread is a helper function that creates the thread for
+// executing the template with the provided options.
+func (o *Options) thread(ctx context.Context, name string) *starlark.Thread {
+	thread := &starlark.Thread{Name: name}
+	thread.SetLocal(moduleKey, "")
+	thread.SetMaxExecutionSteps(o.maxExecutionSteps())
+
+	// check if a loader was provided for load()
+	if o == nil || o.Loader == nil {
+		return thread
+	}
+
+	modules := o.Modules
+	if modules == nil {
+		modules = NewModules()
+	}
+
+	thread.Load = modules.load(ctx, o)
+
+	return thread
+}
+
+// execError is a helper function that returns the error for executing the
+// template. The error contains the limit exceeded by the template or the
+// Starlark call stack when the template failed while being evaluated.
+// The parent context is the context provided before applying the timeout.
+//
+// nolint: lll // ignore long line length due to parameters
+func (o *Options) execError(parent, ctx context.Context, thread *starlark.Thread, name string, err error) error {
+	// check if the timeout was exceeded rather than the provided context
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+		return fmt.Errorf("%w for template %s (limit of %s)", ErrExecutionTimeout, name, o.Timeout)
+	}
+
+	// check if the steps of the thread were exceeded
+	if thread.ExecutionSteps() >= o.maxExecutionSteps() {
+		return fmt.Errorf("%w for template %s (limit of %d steps)", ErrExecutionStepLimit, name, o.maxExecutionSteps())
+	}
+
+	// check if the error contains the call stack
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return &backtraceError{err: evalErr}
+	}
+
+	return err
+}
+
+// backtraceError represents an error from evaluating
+// a template that includes the Starlark call stack.
+type backtraceError struct {
+	err *starlark.EvalError
+}
+
+// Error returns the error message with the call stack.
+func (e *backtraceError) Error() string {
+	return e.err.Backtrace()
+}
+
+// Unwrap returns the error from evaluating the template.
+func (e *backtraceError) Unwrap() error {
+	return e.err
+}
+
+// checkOutput is a helper function that verifies the size
+// of the pipeline returned by the template is within the limit.
+//
+// nolint: lll // detailed error message
+func (o *Options) checkOutput(size int, name string) error {
+	if o == nil || o.MaxOutputSize <= 0 || size <= o.MaxOutputSize {
+		return nil
+	}
+
+	return fmt.Errorf("%w for template %s (%d bytes with limit of %d bytes)", ErrOutputLimit, name, size, o.MaxOutputSize)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-vela/types/raw"
+	"github.com/go-vela/types/yaml"
+)
+
+func TestStarlark_RenderStepWithOptions_Limits(t *testing.T) {
+	// setup types
+	loop := `
+def main(ctx):
+    foo = 0
+    for i in range(1, 100000000):
+        foo = foo + i
+    return {"steps": []}
+`
+
+	basic := `
+def main(ctx):
+    return {"steps": [{"name": "build", "image": "alpine", "commands": ["echo hello"]}]}
+`
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template:    yaml.StepTemplate{Name: "limits"},
+	}
+
+	// setup tests
+	tests := []struct {
+		name    string
+		tmpl    string
+		opts    *Options
+		wantErr error
+		want    string
+	}{
+		{
+			name:    "default step limit",
+			tmpl:    loop,
+			wantErr: ErrExecutionStepLimit,
+			want:    "execution step limit exceeded for template limits (limit of 5000 steps)",
+		},
+		{
+			name:    "step limit",
+			tmpl:    loop,
+			opts:    &Options{MaxExecutionSteps: 100},
+			wantErr: ErrExecutionStepLimit,
+			want:    "execution step limit exceeded for template limits (limit of 100 steps)",
+		},
+		{
+			name:    "timeout",
+			tmpl:    loop,
+			opts:    &Options{MaxExecutionSteps: 1 << 40, Timeout: 10 * time.Millisecond},
+			wantErr: ErrExecutionTimeout,
+			want:    "execution timeout exceeded for template limits (limit of 10ms)",
+		},
+		{
+			name:    "output limit",
+			tmpl:    basic,
+			opts:    &Options{MaxOutputSize: 10},
+			wantErr: ErrOutputLimit,
+			want:    "output size limit exceeded for template limits",
+		},
+		{
+			name: "within limits",
+			tmpl: basic,
+			opts: &Options{MaxExecutionSteps: 100, Timeout: time.Minute, MaxOutputSize: 1024},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		_, err := RenderStepWithOptions(context.Background(), test.tmpl, step, test.opts)
+
+		if test.wantErr == nil {
+			if err != nil {
+				t.Errorf("RenderStepWithOptions for %s returned err: %v", test.name, err)
+			}
+
+			continue
+		}
+
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("RenderStepWithOptions for %s returned err %v, want %v", test.name, err, test.wantErr)
+
+			continue
+		}
+
+		if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("RenderStepWithOptions for %s returned err %v, want %s", test.name, err, test.want)
+		}
+	}
+}
+
+func TestStarlark_RenderBuildWithOptions_Limits(t *testing.T) {
+	// setup types
+	tmpl := `
+def main(ctx):
+    foo = 0
+    for i in range(1, 100000000):
+        foo = foo + i
+    return {"version": "1"}
+`
+
+	_, err := RenderBuildWithOptions(context.Background(), tmpl, map[string]string{}, nil, &Options{MaxExecutionSteps: 100})
+	if !errors.Is(err, ErrExecutionStepLimit) {
+		t.Errorf("RenderBuildWithOptions returned err %v, want %v", err, ErrExecutionStepLimit)
+	}
+}
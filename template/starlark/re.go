@@ -0,0 +1,212 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// reModule is a Starlark module for matching strings with regular
+// expressions. The expressions use the RE2 syntax which guarantees
+// the matching runs in time linear in the size of the input.
+//
+// https://github.com/google/re2/wiki/Syntax
+//
+// nolint: gochecknoglobals // module is shared between threads
+var reModule = &starlarkstruct.Module{
+	Name: "re",
+	Members: starlark.StringDict{
+		"escape":   starlark.NewBuiltin("re.escape", reEscape),
+		"find":     starlark.NewBuiltin("re.find", reFind),
+		"find_all": starlark.NewBuiltin("re.find_all", reFindAll),
+		"groups":   starlark.NewBuiltin("re.groups", reGroups),
+		"match":    starlark.NewBuiltin("re.match", reMatch),
+		"replace":  starlark.NewBuiltin("re.replace", reReplace),
+		"split":    starlark.NewBuiltin("re.split", reSplit),
+	},
+}
+
+// compile is a helper function that compiles the regular expression.
+func compile(b *starlark.Builtin, pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid pattern %q: %w", b.Name(), pattern, err)
+	}
+
+	return re, nil
+}
+
+// reEscape returns the string with all regular expression metacharacters escaped.
+//
+// nolint: lll // ignore long line length due to parameters
+func reEscape(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var s string
+
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "s", &s)
+	if err != nil {
+		return nil, err
+	}
+
+	return starlark.String(regexp.QuoteMeta(s)), nil
+}
+
+// reMatch returns if the string contains a match of the pattern.
+//
+// nolint: lll // ignore long line length due to parameters
+func reMatch(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, s string
+
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "s", &s)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := compile(b, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return starlark.Bool(re.MatchString(s)), nil
+}
+
+// reFind returns the leftmost match of the pattern in the string or None.
+//
+// nolint: lll // ignore long line length due to parameters
+func reFind(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, s string
+
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "s", &s)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := compile(b, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := re.FindStringIndex(s)
+	if loc == nil {
+		return starlark.None, nil
+	}
+
+	return starlark.String(s[loc[0]:loc[1]]), nil
+}
+
+// reFindAll returns a list of the successive matches of the
+// pattern in the string, limited to n matches when n >= 0.
+//
+// nolint: lll // ignore long line length due to parameters
+func reFindAll(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, s string
+
+	n := -1
+
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "s", &s, "n?", &n)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := compile(b, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []starlark.Value{}
+	for _, match := range re.FindAllString(s, n) {
+		matches = append(matches, starlark.String(match))
+	}
+
+	return starlark.NewList(matches), nil
+}
+
+// reGroups returns a list of the leftmost match of the pattern in the
+// string followed by the submatches for each group, or None.
+// Groups that didn't participate in the match are None.
+//
+// nolint: lll // ignore long line length due to parameters
+func reGroups(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, s string
+
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "s", &s)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := compile(b, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return starlark.None, nil
+	}
+
+	groups := []starlark.Value{}
+	for i := 0; i < len(loc); i += 2 {
+		if loc[i] < 0 {
+			groups = append(groups, starlark.None)
+
+			continue
+		}
+
+		groups = append(groups, starlark.String(s[loc[i]:loc[i+1]]))
+	}
+
+	return starlark.NewList(groups), nil
+}
+
+// reReplace returns a copy of the string with the matches of the pattern
+// replaced by the replacement. Inside the replacement, $1 or ${name}
+// are expanded to the text matched by the corresponding group.
+//
+// nolint: lll // ignore long line length due to parameters
+func reReplace(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, s, repl string
+
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "s", &s, "repl", &repl)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := compile(b, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return starlark.String(re.ReplaceAllString(s, repl)), nil
+}
+
+// reSplit returns a list of the substrings between the matches of
+// the pattern in the string, limited to n substrings when n >= 0.
+//
+// nolint: lll // ignore long line length due to parameters
+func reSplit(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, s string
+
+	n := -1
+
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "s", &s, "n?", &n)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := compile(b, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []starlark.Value{}
+	for _, part := range re.Split(s, n) {
+		parts = append(parts, starlark.String(part))
+	}
+
+	return starlark.NewList(parts), nil
+}
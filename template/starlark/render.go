@@ -6,9 +6,11 @@ package starlark
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 
+	"github.com/go-vela/compiler/template"
 	"github.com/go-vela/types/raw"
 
 	yaml "github.com/buildkite/yaml"
@@ -32,64 +34,107 @@ var (
 
 // RenderStep combines the template with the step in the yaml pipeline.
 //
-// nolint: funlen,lll // ignore function length due to comments
+// nolint: lll // ignore long line length due to return args
 func RenderStep(tmpl string, s *types.Step) (types.StepSlice, types.SecretSlice, types.ServiceSlice, raw.StringSliceMap, error) {
-	config := new(types.Build)
+	return RenderStepWithContext(context.Background(), tmpl, s)
+}
 
-	thread := &starlark.Thread{Name: s.Name}
-	// arbitrarily limiting the steps of the thread to 5000 to help prevent infinite loops
-	// may need to further investigate spawning a separate POSIX process if user input is problematic
-	// see https://github.com/google/starlark-go/issues/160#issuecomment-466794230 for further details
-	//
-	// nolint: gomnd // ignore magic number
-	thread.SetMaxExecutionSteps(5000)
-	globals, err := starlark.ExecFile(thread, s.Template.Name, tmpl, nil)
+// RenderStepWithContext combines the template with the step in the yaml
+// pipeline and cancels the execution when the provided context is done.
+//
+// nolint: lll // ignore long line length due to return args
+func RenderStepWithContext(ctx context.Context, tmpl string, s *types.Step) (types.StepSlice, types.SecretSlice, types.ServiceSlice, raw.StringSliceMap, error) {
+	result, err := RenderStepWithOptions(ctx, tmpl, s, nil)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
 
+	return result.Build.Steps, result.Build.Secrets, result.Build.Services, result.Build.Environment, nil
+}
+
+// RenderStepWithOptions combines the template with the step in the yaml
+// pipeline using the provided options and cancels the execution when
+// the provided context is done. The result contains the complete pipeline
+// produced by the template, i.e. including the templates it declares.
+//
+// nolint: funlen,gocyclo,lll // ignore function length due to comments
+func RenderStepWithOptions(ctx context.Context, tmpl string, s *types.Step, opts *Options) (*template.Result, error) {
+	config := new(types.Build)
+
+	// limit the execution time of the template
+	parent := ctx
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	thread := opts.thread(ctx, s.Name)
+
+	// cancel the thread when the context is done
+	stop := cancelOnDone(ctx, thread)
+	defer stop()
+
+	globals, err := starlark.ExecFile(thread, s.Template.Name, tmpl, predeclared)
+	if err != nil {
+		return nil, opts.execError(parent, ctx, thread, s.Template.Name, err)
+	}
+
 	// check the provided template has a main function
 	mainVal, ok := globals["main"]
 	if !ok {
-		return nil, nil, nil, nil, fmt.Errorf("%s: %s", ErrMissingMainFunc, s.Template.Name)
+		return nil, fmt.Errorf("%s: %s", ErrMissingMainFunc, s.Template.Name)
 	}
 
 	// check the provided main is a function
 	main, ok := mainVal.(starlark.Callable)
 	if !ok {
-		return nil, nil, nil, nil, fmt.Errorf("%s: %s", ErrInvalidMainFunc, s.Template.Name)
+		return nil, fmt.Errorf("%s: %s", ErrInvalidMainFunc, s.Template.Name)
+	}
+
+	// capture the variables for the template from the declared metadata and schema
+	tmplVars, err := opts.prepare(globals, s.Template.Name, s.Template.Variables)
+	if err != nil {
+		return nil, err
 	}
 
 	// load the user provided vars into a starlark type
-	userVars, err := convertTemplateVars(s.Template.Variables)
+	userVars, err := convertTemplateVars(tmplVars)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, err
 	}
 
 	// load the platform provided vars into a starlark type
 	velaVars, err := convertPlatformVars(s.Environment, s.Name)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, err
 	}
 
 	// add the user and platform vars to a context to be used
 	// within the template caller i.e. ctx["vela"] or ctx["vars"]
-	context := starlark.NewDict(0)
-	err = context.SetKey(starlark.String("vela"), velaVars)
+	ctxDict := starlark.NewDict(0)
+	err = ctxDict.SetKey(starlark.String("vela"), velaVars)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, err
 	}
-	err = context.SetKey(starlark.String("vars"), userVars)
+
+	// track the user vars referenced by the template in strict mode
+	var vars starlark.Value = userVars
+
+	// track the referenced variables in strict mode or to warn about unused variables
+	tracked := newTrackedVars(userVars, s.Template.Variables)
+	if opts != nil && (opts.Strict || opts.Warn != nil) {
+		vars = tracked
+	}
+
+	err = ctxDict.SetKey(starlark.String("vars"), vars)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, err
 	}
 
-	args := starlark.Tuple([]starlark.Value{context})
+	args := starlark.Tuple([]starlark.Value{ctxDict})
 
 	// execute Starlark program from Go.
 	mainVal, err = starlark.Call(thread, main, args, nil)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, opts.execError(parent, ctx, thread, s.Template.Name, err)
 	}
 
 	buf := new(bytes.Buffer)
@@ -102,7 +147,7 @@ func RenderStep(tmpl string, s *types.Step) (types.StepSlice, types.SecretSlice,
 			buf.WriteString("---\n")
 			err = writeJSON(buf, item)
 			if err != nil {
-				return nil, nil, nil, nil, err
+				return nil, err
 			}
 			buf.WriteString("\n")
 		}
@@ -110,17 +155,37 @@ func RenderStep(tmpl string, s *types.Step) (types.StepSlice, types.SecretSlice,
 		buf.WriteString("---\n")
 		err = writeJSON(buf, v)
 		if err != nil {
-			return nil, nil, nil, nil, err
+			return nil, err
 		}
 	default:
-		return nil, nil, nil, nil, fmt.Errorf("%s: %s", ErrInvalidPipelineReturn, mainVal.Type())
+		return nil, fmt.Errorf("%s: %s", ErrInvalidPipelineReturn, mainVal.Type())
+	}
+
+	// check the template referenced every user var
+	//
+	// the output is converted first since returning
+	// the vars in the pipeline uses every variable
+	if opts != nil && (opts.Strict || opts.Warn != nil) {
+		err = tracked.unused(s.Template.Name)
+		if err != nil {
+			if opts.Strict {
+				return nil, err
+			}
+
+			opts.Warn(err.Error())
+		}
+	}
+
+	// check the size of the pipeline produced by the template
+	err = opts.checkOutput(buf.Len(), s.Template.Name)
+	if err != nil {
+		return nil, err
 	}
 
 	// unmarshal the template to the pipeline
 	err = yaml.Unmarshal(buf.Bytes(), config)
 	if err != nil {
-		// nolint: lll // ignore long line length due to return args
-		return types.StepSlice{}, types.SecretSlice{}, types.ServiceSlice{}, raw.StringSliceMap{}, fmt.Errorf("unable to unmarshal yaml: %v", err)
+		return nil, fmt.Errorf("unable to unmarshal yaml: %v", err)
 	}
 
 	// ensure all templated steps have template prefix
@@ -128,25 +193,51 @@ func RenderStep(tmpl string, s *types.Step) (types.StepSlice, types.SecretSlice,
 		config.Steps[index].Name = fmt.Sprintf("%s_%s", s.Name, newStep.Name)
 	}
 
-	return config.Steps, config.Secrets, config.Services, config.Environment, nil
+	return &template.Result{Build: config, Raw: buf.Bytes()}, nil
 }
 
 // RenderBuild renders the templated build.
 func RenderBuild(b string, envs map[string]string) (*types.Build, error) {
-	config := new(types.Build)
+	return RenderBuildWithContext(context.Background(), b, envs)
+}
 
-	thread := &starlark.Thread{Name: "templated-base"}
-	// arbitrarily limiting the steps of the thread to 5000 to help prevent infinite loops
-	// may need to further investigate spawning a separate POSIX process if user input is problematic
-	// see https://github.com/google/starlark-go/issues/160#issuecomment-466794230 for further details
-	//
-	// nolint: gomnd // ignore magic number
-	thread.SetMaxExecutionSteps(5000)
-	globals, err := starlark.ExecFile(thread, "templated-base", b, nil)
+// RenderBuildWithContext renders the templated build and cancels
+// the execution when the provided context is done.
+//
+// nolint: lll // ignore long line length due to return args
+func RenderBuildWithContext(ctx context.Context, b string, envs map[string]string) (*types.Build, error) {
+	result, err := RenderBuildWithOptions(ctx, b, envs, nil, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	return result.Build, nil
+}
+
+// RenderBuildWithOptions renders the templated build with the provided
+// pipeline variables using the provided options and cancels the
+// execution when the provided context is done.
+//
+// nolint: funlen,lll // ignore function length due to comments
+func RenderBuildWithOptions(ctx context.Context, b string, envs map[string]string, vars map[string]interface{}, opts *Options) (*template.Result, error) {
+	config := new(types.Build)
+
+	// limit the execution time of the template
+	parent := ctx
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	thread := opts.thread(ctx, "templated-base")
+
+	// cancel the thread when the context is done
+	stop := cancelOnDone(ctx, thread)
+	defer stop()
+
+	globals, err := starlark.ExecFile(thread, "templated-base", b, predeclared)
+	if err != nil {
+		return nil, opts.execError(parent, ctx, thread, "templated-base", err)
+	}
+
 	// check the provided template has a main function
 	mainVal, ok := globals["main"]
 	if !ok {
@@ -159,26 +250,36 @@ func RenderBuild(b string, envs map[string]string) (*types.Build, error) {
 		return nil, fmt.Errorf("%s: %s", ErrInvalidMainFunc, "templated-base")
 	}
 
+	// load the pipeline vars into a starlark type
+	userVars, err := convertTemplateVars(vars)
+	if err != nil {
+		return nil, err
+	}
+
 	// load the platform provided vars into a starlark type
 	velaVars, err := convertPlatformVars(envs, "")
 	if err != nil {
 		return nil, err
 	}
 
-	// add the user and platform vars to a context to be used
+	// add the pipeline and platform vars to a context to be used
 	// within the template caller i.e. ctx["vela"] or ctx["vars"]
-	context := starlark.NewDict(0)
-	err = context.SetKey(starlark.String("vela"), velaVars)
+	ctxDict := starlark.NewDict(0)
+	err = ctxDict.SetKey(starlark.String("vela"), velaVars)
+	if err != nil {
+		return nil, err
+	}
+	err = ctxDict.SetKey(starlark.String("vars"), userVars)
 	if err != nil {
 		return nil, err
 	}
 
-	args := starlark.Tuple([]starlark.Value{context})
+	args := starlark.Tuple([]starlark.Value{ctxDict})
 
 	// execute Starlark program from Go.
 	mainVal, err = starlark.Call(thread, main, args, nil)
 	if err != nil {
-		return nil, err
+		return nil, opts.execError(parent, ctx, thread, "templated-base", err)
 	}
 
 	buf := new(bytes.Buffer)
@@ -205,11 +306,41 @@ func RenderBuild(b string, envs map[string]string) (*types.Build, error) {
 		return nil, fmt.Errorf("%s: %s", ErrInvalidPipelineReturn, mainVal.Type())
 	}
 
+	// check the size of the pipeline produced by the template
+	err = opts.checkOutput(buf.Len(), "templated-base")
+	if err != nil {
+		return nil, err
+	}
+
 	// unmarshal the template to the pipeline
 	err = yaml.Unmarshal(buf.Bytes(), config)
 	if err != nil {
 		return nil, fmt.Errorf("unable to unmarshal yaml: %v", err)
 	}
 
-	return config, nil
+	return &template.Result{Build: config, Raw: buf.Bytes()}, nil
+}
+
+// cancelOnDone is a helper function that cancels the Starlark thread
+// when the context is done. The returned function must be called
+// once the thread has finished executing to release the watcher.
+func cancelOnDone(ctx context.Context, thread *starlark.Thread) func() {
+	// check if the context has already been canceled
+	if err := ctx.Err(); err != nil {
+		thread.Cancel(err.Error())
+
+		return func() {}
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+
+	return func() { close(done) }
 }
@@ -5,7 +5,9 @@
 package starlark
 
 import (
+	"context"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	goyaml "github.com/buildkite/yaml"
@@ -137,3 +139,84 @@ func TestNative_RenderBuild(t *testing.T) {
 		})
 	}
 }
+
+func TestStarlark_RenderBuildWithOptions_Vars(t *testing.T) {
+	// setup types
+	sFile, err := ioutil.ReadFile("testdata/build/with_vars/build.star")
+	if err != nil {
+		t.Error(err)
+	}
+
+	wFile, err := ioutil.ReadFile("testdata/build/with_vars/want.yml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	want := &yaml.Build{}
+
+	err = goyaml.Unmarshal(wFile, want)
+	if err != nil {
+		t.Error(err)
+	}
+
+	vars := map[string]interface{}{
+		"image": "golang:latest",
+		"steps": []interface{}{"foo", "bar"},
+	}
+
+	// run test
+	got, err := RenderBuildWithOptions(context.Background(), string(sFile), map[string]string{}, vars, nil)
+	if err != nil {
+		t.Errorf("RenderBuildWithOptions returned err: %v", err)
+	}
+
+	if diff := cmp.Diff(want, got.Build); diff != "" {
+		t.Errorf("RenderBuildWithOptions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStarlark_RenderStepWithContext_Canceled(t *testing.T) {
+	// setup types
+	sFile, err := ioutil.ReadFile("testdata/step/basic/step.yml")
+	if err != nil {
+		t.Error(err)
+	}
+
+	b := &yaml.Build{}
+
+	err = goyaml.Unmarshal(sFile, b)
+	if err != nil {
+		t.Error(err)
+	}
+
+	tmpl, err := ioutil.ReadFile("testdata/step/basic/template.py")
+	if err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// run test
+	_, _, _, _, err = RenderStepWithContext(ctx, string(tmpl), b.Steps[0])
+	if err == nil {
+		t.Errorf("RenderStepWithContext should have returned err")
+	}
+}
+
+func TestStarlark_RenderBuildWithContext_Canceled(t *testing.T) {
+	// setup types
+	sFile, err := ioutil.ReadFile("testdata/build/basic/build.star")
+	if err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// run test
+	_, err = RenderBuildWithContext(ctx, string(sFile), map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("RenderBuildWithContext returned err %v, want %v", err, context.Canceled)
+	}
+}
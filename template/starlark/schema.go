@@ -0,0 +1,100 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-vela/compiler/template"
+
+	"go.starlark.net/starlark"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// SchemaGlobal is the name of the global declaring the variables
+	// for the template. The name is reserved in every template so
+	// it should not be used for other values.
+	//
+	//	vela_schema = {
+	//	    "image": {"type": "string", "required": True},
+	//	}
+	SchemaGlobal = "vela_schema"
+
+	// MetadataGlobal is the name of the global declaring the metadata
+	// for the template. The name is reserved in every template so
+	// it should not be used for other values.
+	//
+	//	vela_metadata = {
+	//	    "version": "1.2.0",
+	//	    "deprecated": True,
+	//	    "replacement": "github.com/octocat/hello-world/.vela/build.star@v2",
+	//	}
+	MetadataGlobal = "vela_metadata"
+)
+
+// declarations is a helper function that returns the metadata and the
+// schema declared by the globals of the executed template. The metadata
+// and the schema are nil when the template doesn't declare them.
+//
+// nolint: lll // ignore long line length due to return args
+func declarations(globals starlark.StringDict, name string) (*template.Metadata, template.Schema, error) {
+	var (
+		metadata *template.Metadata
+		schema   template.Schema
+	)
+
+	// check if the template declares metadata
+	if value, ok := globals[MetadataGlobal]; ok {
+		metadata = new(template.Metadata)
+
+		err := decodeGlobal(value, metadata)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s in template %s: %w", MetadataGlobal, name, err)
+		}
+	}
+
+	// check if the template declares a schema
+	if value, ok := globals[SchemaGlobal]; ok {
+		err := decodeGlobal(value, &schema)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s in template %s: %w", SchemaGlobal, name, err)
+		}
+	}
+
+	return metadata, schema, nil
+}
+
+// decodeGlobal is a helper function that decodes the value of a global into v.
+func decodeGlobal(value starlark.Value, v interface{}) error {
+	buf := new(bytes.Buffer)
+
+	err := writeJSON(buf, value)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(buf.Bytes(), v)
+}
+
+// prepare is a helper function that provides the metadata and the schema
+// declared by the executed template to the prepare function from the
+// options and returns the variables for rendering the template. The
+// provided variables are returned when no prepare function is set.
+//
+// nolint: lll // ignore long line length due to parameters
+func (o *Options) prepare(globals starlark.StringDict, name string, vars map[string]interface{}) (map[string]interface{}, error) {
+	if o == nil || o.Prepare == nil {
+		return vars, nil
+	}
+
+	metadata, schema, err := declarations(globals, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Prepare(metadata, schema)
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-vela/compiler/template"
+	"github.com/go-vela/types/raw"
+	"github.com/go-vela/types/yaml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStarlark_RenderStepWithOptions_Prepare(t *testing.T) {
+	// setup types
+	tmpl := `
+vela_metadata = {
+    "version": "1.2.0",
+    "deprecated": True,
+    "replacement": "sample@v2",
+    "min_compiler_version": "0.10.0",
+}
+
+vela_schema = {
+    "image": {"type": "string", "required": True, "description": "image for the step"},
+    "retries": {"type": "integer", "default": 3},
+}
+
+# globals named schema and metadata are not reserved
+schema = "unrelated"
+metadata = ["unrelated"]
+
+def main(ctx):
+    return {"steps": [{"name": "build", "image": ctx["vars"]["image"]}]}
+`
+
+	wantMetadata := &template.Metadata{
+		Version:            "1.2.0",
+		Deprecated:         true,
+		Replacement:        "sample@v2",
+		MinCompilerVersion: "0.10.0",
+	}
+
+	wantSchema := template.Schema{
+		"image":   {Type: "string", Required: true, Description: "image for the step"},
+		"retries": {Type: "integer", Default: 3},
+	}
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template: yaml.StepTemplate{
+			Name:      "schema",
+			Variables: map[string]interface{}{"image": "ignored"},
+		},
+	}
+
+	calls := 0
+
+	opts := &Options{
+		Prepare: func(metadata *template.Metadata, schema template.Schema) (map[string]interface{}, error) {
+			calls++
+
+			if diff := cmp.Diff(wantMetadata, metadata); diff != "" {
+				t.Errorf("Prepare metadata mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(wantSchema, schema); diff != "" {
+				t.Errorf("Prepare schema mismatch (-want +got):\n%s", diff)
+			}
+
+			return map[string]interface{}{"image": "alpine"}, nil
+		},
+	}
+
+	// run test
+	got, err := RenderStepWithOptions(context.Background(), tmpl, step, opts)
+	if err != nil {
+		t.Errorf("RenderStepWithOptions returned err: %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("RenderStepWithOptions called Prepare %d times, want 1", calls)
+	}
+
+	if len(got.Build.Steps) != 1 || got.Build.Steps[0].Image != "alpine" {
+		t.Errorf("RenderStepWithOptions is %v, want step with image alpine", got.Build.Steps)
+	}
+}
+
+func TestStarlark_RenderStepWithOptions_PrepareUndeclared(t *testing.T) {
+	// setup types
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template:    yaml.StepTemplate{Name: "sample"},
+	}
+
+	opts := &Options{
+		Prepare: func(metadata *template.Metadata, schema template.Schema) (map[string]interface{}, error) {
+			if metadata != nil || schema != nil {
+				t.Errorf("Prepare is %v and %v, want nil", metadata, schema)
+			}
+
+			return nil, nil
+		},
+	}
+
+	// run test
+	_, err := RenderStepWithOptions(context.Background(), "def main(ctx):\n    return {\"steps\": []}\n", step, opts)
+	if err != nil {
+		t.Errorf("RenderStepWithOptions returned err: %v", err)
+	}
+}
+
+func TestStarlark_RenderStepWithOptions_PrepareInvalid(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		name string
+		tmpl string
+	}{
+		{
+			name: "schema",
+			tmpl: "vela_schema = [\"image\"]\n\ndef main(ctx):\n    return {\"steps\": []}\n",
+		},
+		{
+			name: "metadata",
+			tmpl: "vela_metadata = [\"1.2.0\"]\n\ndef main(ctx):\n    return {\"steps\": []}\n",
+		},
+	}
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template:    yaml.StepTemplate{Name: "sample"},
+	}
+
+	opts := &Options{
+		Prepare: func(*template.Metadata, template.Schema) (map[string]interface{}, error) {
+			return nil, nil
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		_, err := RenderStepWithOptions(context.Background(), test.tmpl, step, opts)
+		if err == nil {
+			t.Errorf("RenderStepWithOptions for %s should have returned err", test.name)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -170,6 +171,8 @@ func writeJSON(out *bytes.Buffer, v starlark.Value) error {
 	}
 
 	switch v := v.(type) {
+	case *trackedVars:
+		return writeJSON(out, v.value())
 	case starlark.NoneType:
 		_, err := out.WriteString("null")
 		if err != nil {
@@ -267,6 +270,23 @@ func writeJSON(out *bytes.Buffer, v starlark.Value) error {
 		if err != nil {
 			logrus.Error(err)
 		}
+	case *starlarkstruct.Struct:
+		// convert the struct to a dict ordered by the field names
+		d := starlark.NewDict(len(v.AttrNames()))
+
+		for _, name := range v.AttrNames() {
+			attr, err := v.Attr(name)
+			if err != nil {
+				return err
+			}
+
+			err = d.SetKey(starlark.String(name), attr)
+			if err != nil {
+				return err
+			}
+		}
+
+		return writeJSON(out, d)
 	default:
 		return fmt.Errorf("%s: %v", ErrUnableToConvertJSON, v)
 	}
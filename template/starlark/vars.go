@@ -0,0 +1,170 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+// trackedVars represents the variables provided to a template as
+// ctx["vars"] that captures the variables referenced by the template.
+type trackedVars struct {
+	dict *starlark.Dict
+	// keys contains the names of the variables provided by the step,
+	// excluding the defaults from the schema and the variables
+	// set by the template
+	keys []string
+	used map[string]bool
+	// all is set when the template references the variables as a whole
+	all bool
+}
+
+// compile-time checks the tracked variables behave like a dict
+var (
+	_ starlark.IterableMapping = (*trackedVars)(nil)
+	_ starlark.HasSetKey       = (*trackedVars)(nil)
+	_ starlark.HasAttrs        = (*trackedVars)(nil)
+	_ starlark.Sequence        = (*trackedVars)(nil)
+)
+
+// newTrackedVars returns the tracked variables for the dict
+// with the names of the variables provided by the step.
+func newTrackedVars(dict *starlark.Dict, provided map[string]interface{}) *trackedVars {
+	keys := []string{}
+	for k := range provided {
+		keys = append(keys, k)
+	}
+
+	return &trackedVars{
+		dict: dict,
+		keys: keys,
+		used: make(map[string]bool),
+	}
+}
+
+// String returns the string representation of the variables.
+func (v *trackedVars) String() string { return v.dict.String() }
+
+// Type returns the type of the variables.
+func (v *trackedVars) Type() string { return v.dict.Type() }
+
+// Freeze freezes the variables.
+func (v *trackedVars) Freeze() { v.dict.Freeze() }
+
+// Truth returns if the variables are not empty.
+func (v *trackedVars) Truth() starlark.Bool { return v.dict.Truth() }
+
+// Hash returns an error since the variables are a dict.
+func (v *trackedVars) Hash() (uint32, error) { return v.dict.Hash() }
+
+// Len returns the number of variables.
+func (v *trackedVars) Len() int { return v.dict.Len() }
+
+// Get returns the variable for the key, i.e. ctx["vars"]["foo"] or "foo" in ctx["vars"].
+func (v *trackedVars) Get(k starlark.Value) (starlark.Value, bool, error) {
+	v.track(k)
+
+	return v.dict.Get(k)
+}
+
+// SetKey sets the variable for the key, i.e. ctx["vars"]["foo"] = "bar".
+func (v *trackedVars) SetKey(k, value starlark.Value) error {
+	return v.dict.SetKey(k, value)
+}
+
+// Iterate returns an iterator for the names of the variables.
+func (v *trackedVars) Iterate() starlark.Iterator {
+	v.all = true
+
+	return v.dict.Iterate()
+}
+
+// Items returns the names and values of the variables.
+func (v *trackedVars) Items() []starlark.Tuple {
+	v.all = true
+
+	return v.dict.Items()
+}
+
+// Attr returns the method of the dict for the name, i.e. ctx["vars"].get("foo").
+//
+// nolint: lll // ignore long line length due to parameters
+func (v *trackedVars) Attr(name string) (starlark.Value, error) {
+	method, err := v.dict.Attr(name)
+	if err != nil || method == nil {
+		return method, err
+	}
+
+	switch name {
+	case "get":
+		return starlark.NewBuiltin(name, func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			if len(args) > 0 {
+				v.track(args[0])
+			}
+
+			return starlark.Call(thread, method, args, kwargs)
+		}), nil
+	case "keys", "items", "values":
+		v.all = true
+	}
+
+	return method, nil
+}
+
+// AttrNames returns the names of the methods of the dict.
+func (v *trackedVars) AttrNames() []string { return v.dict.AttrNames() }
+
+// value returns the dict for the variables when the template uses
+// the variables as a whole, i.e. returns them in the pipeline.
+func (v *trackedVars) value() *starlark.Dict {
+	v.all = true
+
+	return v.dict
+}
+
+// unwrapVars is a helper function that returns the dict
+// for the value when it contains the tracked variables.
+func unwrapVars(v starlark.Value) starlark.Value {
+	if tracked, ok := v.(*trackedVars); ok {
+		return tracked.value()
+	}
+
+	return v
+}
+
+// track is a helper function that captures the variable for the key.
+func (v *trackedVars) track(k starlark.Value) {
+	if s, ok := k.(starlark.String); ok {
+		v.used[string(s)] = true
+	}
+}
+
+// unused returns an error with the sorted names of the
+// variables that were never referenced by the template.
+func (v *trackedVars) unused(name string) error {
+	if v.all {
+		return nil
+	}
+
+	unused := []string{}
+
+	for _, k := range v.keys {
+		if !v.used[k] {
+			unused = append(unused, k)
+		}
+	}
+
+	if len(unused) == 0 {
+		return nil
+	}
+
+	sort.Strings(unused)
+
+	return fmt.Errorf("template %s has unused variables: %s", name, strings.Join(unused, ", "))
+}
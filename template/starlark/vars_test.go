@@ -0,0 +1,264 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-vela/compiler/template"
+
+	"github.com/go-vela/types/raw"
+	"github.com/go-vela/types/yaml"
+)
+
+func TestStarlark_RenderStepWithOptions_Strict(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		name    string
+		body    string
+		strict  bool
+		wantErr string
+	}{
+		{
+			name: "lenient",
+			body: `image = ctx["vars"]["image"]`,
+		},
+		{
+			name:    "unused",
+			body:    `image = ctx["vars"]["image"]`,
+			strict:  true,
+			wantErr: "template strict has unused variables: pull, tag",
+		},
+		{
+			name:   "referenced",
+			body:   `image = ctx["vars"]["image"] + ctx["vars"].get("tag", "") + ("pull" in ctx["vars"] and "" or "")`,
+			strict: true,
+		},
+		{
+			name:   "iterated",
+			body:   `image = ",".join([k for k in ctx["vars"]])`,
+			strict: true,
+		},
+		{
+			name:   "items",
+			body:   `image = ",".join([k for k, v in ctx["vars"].items()])`,
+			strict: true,
+		},
+		{
+			name:    "missing",
+			body:    `image = ctx["vars"]["imag"]`,
+			strict:  true,
+			wantErr: `key "imag" not in dict`,
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		tmpl := `
+def main(ctx):
+    ` + test.body + `
+    return {"steps": [{"name": "build", "image": image}]}
+`
+
+		step := &yaml.Step{
+			Name:        "sample",
+			Environment: raw.StringSliceMap{},
+			Template: yaml.StepTemplate{
+				Name: "strict",
+				Variables: map[string]interface{}{
+					"image": "alpine",
+					"tag":   ":latest",
+					"pull":  "always",
+				},
+			},
+		}
+
+		_, err := RenderStepWithOptions(context.Background(), tmpl, step, &Options{Strict: test.strict})
+
+		if len(test.wantErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("RenderStepWithOptions for %s returned err %v, want %s", test.name, err, test.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("RenderStepWithOptions for %s returned err: %v", test.name, err)
+		}
+	}
+}
+
+func TestStarlark_RenderStepWithOptions_Warn(t *testing.T) {
+	// setup types
+	warnings := []string{}
+
+	tmpl := `
+def main(ctx):
+    return {"steps": [{"name": "build", "image": ctx["vars"]["image"]}]}
+`
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template: yaml.StepTemplate{
+			Name:      "lenient",
+			Variables: map[string]interface{}{"image": "alpine", "tag": "latest"},
+		},
+	}
+
+	opts := &Options{
+		Warn: func(message string) {
+			warnings = append(warnings, message)
+		},
+	}
+
+	// run test
+	_, err := RenderStepWithOptions(context.Background(), tmpl, step, opts)
+	if err != nil {
+		t.Errorf("RenderStepWithOptions returned err: %v", err)
+	}
+
+	want := []string{"template lenient has unused variables: tag"}
+
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("RenderStepWithOptions warnings are %v, want %v", warnings, want)
+	}
+}
+
+func TestStarlark_RenderStepWithOptions_MutateVars(t *testing.T) {
+	// setup tests
+	tests := []struct {
+		name string
+		tmpl string
+		want []*yaml.Step
+	}{
+		{
+			name: "default",
+			tmpl: `
+def main(ctx):
+    v = ctx["vars"]
+    v["image"] = v.get("image", "alpine")
+    v["registry"] = v.get("registry", "docker.io")
+    return {"steps": [{"name": "build", "image": v["registry"] + "/" + v["image"] + v["tag"]}]}
+`,
+			want: []*yaml.Step{
+				{Name: "sample_build", Image: "docker.io/golang:latest", Pull: "not_present"},
+			},
+		},
+		{
+			name: "returned",
+			tmpl: `
+def main(ctx):
+    return {"steps": [{"name": "build", "image": "alpine", "parameters": ctx["vars"]}]}
+`,
+			want: []*yaml.Step{
+				{
+					Name:       "sample_build",
+					Image:      "alpine",
+					Pull:       "not_present",
+					Parameters: map[string]interface{}{"image": "golang", "tag": ":latest"},
+				},
+			},
+		},
+		{
+			name: "constructor",
+			tmpl: `
+def main(ctx):
+    return {"steps": [step(name = "build", image = "alpine", parameters = ctx["vars"])]}
+`,
+			want: []*yaml.Step{
+				{
+					Name:       "sample_build",
+					Image:      "alpine",
+					Pull:       "not_present",
+					Parameters: map[string]interface{}{"image": "golang", "tag": ":latest"},
+				},
+			},
+		},
+	}
+
+	// run tests
+	for _, test := range tests {
+		for _, mode := range []string{"strict", "warn"} {
+			warnings := []string{}
+
+			opts := &Options{Strict: mode == "strict"}
+			if mode == "warn" {
+				opts.Warn = func(message string) {
+					warnings = append(warnings, message)
+				}
+			}
+
+			step := &yaml.Step{
+				Name:        "sample",
+				Environment: raw.StringSliceMap{},
+				Template: yaml.StepTemplate{
+					Name:      "mutate",
+					Variables: map[string]interface{}{"image": "golang", "tag": ":latest"},
+				},
+			}
+
+			got, err := RenderStepWithOptions(context.Background(), test.tmpl, step, opts)
+			if err != nil {
+				t.Errorf("RenderStepWithOptions for %s in %s mode returned err: %v", test.name, mode, err)
+
+				continue
+			}
+
+			if !reflect.DeepEqual(got.Build.Steps, yaml.StepSlice(test.want)) {
+				t.Errorf("RenderStepWithOptions for %s in %s mode is %v, want %v", test.name, mode, got.Build.Steps, test.want)
+			}
+
+			if len(warnings) > 0 {
+				t.Errorf("RenderStepWithOptions for %s in %s mode warnings are %v, want none", test.name, mode, warnings)
+			}
+		}
+	}
+}
+
+func TestStarlark_RenderStepWithOptions_WarnDefaults(t *testing.T) {
+	// setup types
+	warnings := []string{}
+
+	tmpl := `
+def main(ctx):
+    return {"steps": [{"name": "build", "image": ctx["vars"]["image"]}]}
+`
+
+	step := &yaml.Step{
+		Name:        "sample",
+		Environment: raw.StringSliceMap{},
+		Template: yaml.StepTemplate{
+			Name:      "defaults",
+			Variables: map[string]interface{}{"image": "alpine", "tag": "latest"},
+		},
+	}
+
+	opts := &Options{
+		Warn: func(message string) {
+			warnings = append(warnings, message)
+		},
+		// apply a default from the schema that the template never references
+		Prepare: func(metadata *template.Metadata, schema template.Schema) (map[string]interface{}, error) {
+			return map[string]interface{}{"image": "alpine", "tag": "latest", "pull": "always"}, nil
+		},
+	}
+
+	// run test
+	_, err := RenderStepWithOptions(context.Background(), tmpl, step, opts)
+	if err != nil {
+		t.Errorf("RenderStepWithOptions returned err: %v", err)
+	}
+
+	want := []string{"template defaults has unused variables: tag"}
+
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("RenderStepWithOptions warnings are %v, want %v", warnings, want)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2021 Target Brands, Inc. All rights reserved.
+//
+// Use of this source code is governed by the LICENSE file in this repository.
+
+package starlark
+
+import (
+	"fmt"
+	"math/big"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"gopkg.in/yaml.v3"
+)
+
+// maxYAMLDepth is the maximum depth of the nested values
+// encoded to YAML to prevent encoding cyclic values forever.
+const maxYAMLDepth = 100
+
+// yamlModule is a Starlark module for encoding values to YAML and decoding
+// YAML documents. The order of the keys in a mapping is always preserved.
+//
+// nolint: gochecknoglobals // module is shared between threads
+var yamlModule = &starlarkstruct.Module{
+	Name: "yaml",
+	Members: starlark.StringDict{
+		"decode": starlark.NewBuiltin("yaml.decode", yamlDecode),
+		"encode": starlark.NewBuiltin("yaml.encode", yamlEncode),
+	},
+}
+
+// yamlEncode returns the YAML document for the value.
+//
+// nolint: lll // ignore long line length due to parameters
+func yamlEncode(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x starlark.Value
+
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "x", &x)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := toYAMLNode(x, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	return starlark.String(out), nil
+}
+
+// yamlDecode returns the value for the YAML document.
+//
+// nolint: lll // ignore long line length due to parameters
+func yamlDecode(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x string
+
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "x", &x)
+	if err != nil {
+		return nil, err
+	}
+
+	node := new(yaml.Node)
+
+	err = yaml.Unmarshal([]byte(x), node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	// decode the document once to reject excessive aliasing
+	// before expanding the aliases in the document
+	var v interface{}
+
+	err = node.Decode(&v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	value, err := fromYAMLNode(node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	return value, nil
+}
+
+// toYAMLNode is a helper function that converts
+// the Starlark value to a YAML node.
+//
+// nolint: gocyclo,funlen // ignore cyclomatic complexity and function length
+func toYAMLNode(v starlark.Value, depth int) (*yaml.Node, error) {
+	if depth > maxYAMLDepth {
+		return nil, fmt.Errorf("value is nested deeper than %d levels", maxYAMLDepth)
+	}
+
+	node := new(yaml.Node)
+
+	switch v := unwrapVars(v).(type) {
+	case starlark.NoneType:
+		node.Kind = yaml.ScalarNode
+		node.Tag = "!!null"
+		node.Value = "null"
+	case starlark.Bool:
+		return node, node.Encode(bool(v))
+	case starlark.Int:
+		node.Kind = yaml.ScalarNode
+		node.Tag = "!!int"
+		node.Value = v.String()
+	case starlark.Float:
+		return node, node.Encode(float64(v))
+	case starlark.String:
+		return node, node.Encode(string(v))
+	case *starlark.Dict:
+		node.Kind = yaml.MappingNode
+
+		for _, item := range v.Items() {
+			key, err := toYAMLNode(item[0], depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := toYAMLNode(item[1], depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, key, value)
+		}
+	case *starlarkstruct.Struct:
+		node.Kind = yaml.MappingNode
+
+		for _, name := range v.AttrNames() {
+			attr, err := v.Attr(name)
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := toYAMLNode(attr, depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+
+			node.Content = append(node.Content, key, value)
+		}
+	case starlark.Indexable: // Tuple, List
+		node.Kind = yaml.SequenceNode
+
+		for i := 0; i < v.Len(); i++ {
+			value, err := toYAMLNode(v.Index(i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, value)
+		}
+	default:
+		return nil, fmt.Errorf("unable to encode %s to yaml", v.Type())
+	}
+
+	return node, nil
+}
+
+// fromYAMLNode is a helper function that converts
+// the YAML node to a Starlark value.
+//
+// nolint: gocyclo // ignore cyclomatic complexity
+func fromYAMLNode(node *yaml.Node) (starlark.Value, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return starlark.None, nil
+		}
+
+		return fromYAMLNode(node.Content[0])
+	case yaml.AliasNode:
+		return fromYAMLNode(node.Alias)
+	case yaml.MappingNode:
+		d := starlark.NewDict(len(node.Content) / 2)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, err := fromYAMLNode(node.Content[i])
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := fromYAMLNode(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+
+			err = d.SetKey(key, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return d, nil
+	case yaml.SequenceNode:
+		values := make([]starlark.Value, 0, len(node.Content))
+
+		for _, n := range node.Content {
+			value, err := fromYAMLNode(n)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		return starlark.NewList(values), nil
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return starlark.None, nil
+	case "!!bool":
+		var b bool
+
+		err := node.Decode(&b)
+		if err != nil {
+			return nil, err
+		}
+
+		return starlark.Bool(b), nil
+	case "!!int":
+		var i big.Int
+
+		// use the decoded integer to support the YAML prefixes for the base
+		var v interface{}
+
+		err := node.Decode(&v)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := i.SetString(fmt.Sprint(v), 10); !ok {
+			return nil, fmt.Errorf("invalid integer %s", node.Value)
+		}
+
+		return starlark.MakeBigInt(&i), nil
+	case "!!float":
+		var f float64
+
+		err := node.Decode(&f)
+		if err != nil {
+			return nil, err
+		}
+
+		return starlark.Float(f), nil
+	default:
+		return starlark.String(node.Value), nil
+	}
+}
@@ -16,3 +16,21 @@ type Engine interface {
 	// the template with the step.
 	RenderStep(template string, step *yaml.Step) (yaml.StepSlice, error)
 }
+
+// Result represents the pipeline produced by rendering a template.
+type Result struct {
+	// Build is the pipeline produced by the template.
+	Build *yaml.Build
+	// Raw is the yaml configuration produced by the template before
+	// it was unmarshaled into the pipeline, which contains the keys
+	// that aren't supported by the pipeline types. The names of the
+	// steps aren't prefixed with the name of the templated step.
+	Raw []byte
+}
+
+// PrepareFunc represents the function that receives the metadata and
+// the schema declared by a template before the template is rendered
+// and returns the variables for rendering the template, i.e. with the
+// defaults from the schema applied. The metadata and the schema are
+// nil when the template doesn't declare them.
+type PrepareFunc func(*Metadata, Schema) (map[string]interface{}, error)